@@ -0,0 +1,56 @@
+package checkers
+
+import (
+	"fmt"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// 每条内置规则对应的独立 Analyzer，只做检测、从不调用 AI，
+// 可以被 multichecker、go vet -vettool 或其他工具单独引用
+var (
+	UnhandledErrorAnalyzer  = mustAnalyzer("UnhandledError")
+	NilPointerAnalyzer      = mustAnalyzer("NilPointer")
+	ResourceLeakAnalyzer    = mustAnalyzer("ResourceLeak")
+	HardcodedSecretAnalyzer = mustAnalyzer("HardcodedSecret")
+	SQLInjectionAnalyzer    = mustAnalyzer("SQLInjection")
+	GoroutineLeakAnalyzer   = mustAnalyzer("GoroutineLeak")
+)
+
+// NewAnalyzer 将检查器包装为标准的 analysis.Analyzer，缺陷通过 pass.Report 汇报
+func NewAnalyzer(c Checker) *analysis.Analyzer {
+	return &analysis.Analyzer{
+		Name: c.Name(),
+		Doc:  fmt.Sprintf("检测 %s 类缺陷 (默认级别: %s)", c.Category(), c.Severity()),
+		Run: func(pass *analysis.Pass) (interface{}, error) {
+			for _, f := range pass.Files {
+				for _, iss := range ScanFile(pass, f, []Checker{c}) {
+					pass.Report(analysis.Diagnostic{
+						Pos:      iss.Pos,
+						End:      iss.End,
+						Category: iss.Category,
+						Message:  fmt.Sprintf("[%s] %s", iss.Severity, iss.Message),
+					})
+				}
+			}
+			return nil, nil
+		},
+	}
+}
+
+// Analyzers 返回所有已注册检查器对应的 Analyzer（按注册顺序）
+func Analyzers() []*analysis.Analyzer {
+	var res []*analysis.Analyzer
+	for _, c := range All() {
+		res = append(res, NewAnalyzer(c))
+	}
+	return res
+}
+
+func mustAnalyzer(key string) *analysis.Analyzer {
+	c, ok := Lookup(key)
+	if !ok {
+		panic("checkers: 未注册的规则 " + key)
+	}
+	return NewAnalyzer(c)
+}
@@ -1,7 +1,13 @@
 package checkers
 
 import (
+	"bytes"
+	"go/ast"
+	"go/printer"
 	"go/token"
+	"os"
+
+	"golang.org/x/tools/go/analysis"
 )
 
 // Issue 描述一个被发现的代码缺陷
@@ -12,4 +18,30 @@ type Issue struct {
 	Snippet  string    // 代码原始片段
 	Message  string    // 给用户的提示信息
 	Category string    // 缺陷类别：NilPointer / UnhandledError
+
+	Severity   Severity // 严重级别，检查器未填写时取规则的默认级别
+	Confidence float64  // 置信度 (0~1]，启发式判定的规则给出较低的值
+}
+
+// Snippet 返回节点的源码片段。源码取自正在分析的文件：优先使用 pass.ReadFile（复查补丁时提供的是补丁后的内容），
+// 读到的内容与语法树所属文件的大小不一致时（如磁盘上的文件已被改动），按语法树重新打印，绝不用错位的偏移去切片
+func Snippet(pass *analysis.Pass, node ast.Node) string {
+	tf := pass.Fset.File(node.Pos())
+	if tf == nil {
+		return ""
+	}
+	readFile := pass.ReadFile
+	if readFile == nil {
+		readFile = os.ReadFile
+	}
+	content, err := readFile(tf.Name())
+	start, end := tf.Offset(node.Pos()), tf.Offset(node.End())
+	if err == nil && len(content) == tf.Size() && start <= end {
+		return string(content[start:end])
+	}
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, pass.Fset, node); err != nil {
+		return ""
+	}
+	return buf.String()
 }
@@ -7,7 +7,6 @@ import (
 	"go/types"
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/ast/astutil"
-	"os"
 )
 
 func ScanUnhandledError(pass *analysis.Pass, f *ast.File) []Issue {
@@ -39,17 +38,14 @@ func ScanUnhandledError(pass *analysis.Pass, f *ast.File) []Issue {
 
 			if !isHandledInInterval(pass, f, obj, currentAssignEnd, nextAssignPos) {
 				// 提取源码片段
-				start := pass.Fset.Position(as.Pos())
-				end := pass.Fset.Position(as.End())
-				content, _ := os.ReadFile(start.Filename)
-
 				issues = append(issues, Issue{
-					Pos:      as.Pos(),
-					End:      as.End(),
-					VarName:  id.Name,
-					Snippet:  string(content[start.Offset:end.Offset]),
-					Message:  fmt.Sprintf("⚠️ 变量 %s 类型为 error 但未被 if 或 return 处理", id.Name),
-					Category: "UnhandledError",
+					Pos:        as.Pos(),
+					End:        as.End(),
+					VarName:    id.Name,
+					Snippet:    Snippet(pass, as),
+					Message:    fmt.Sprintf("⚠️ 变量 %s 类型为 error 但未被 if 或 return 处理", id.Name),
+					Category:   "UnhandledError",
+					Confidence: 0.9,
 				})
 			}
 
@@ -4,7 +4,6 @@ import (
 	"go/ast"
 	"go/types"
 	"golang.org/x/tools/go/analysis"
-	"os"
 )
 
 func ScanGoroutineLeak(pass *analysis.Pass, f *ast.File) []Issue {
@@ -19,17 +18,14 @@ func ScanGoroutineLeak(pass *analysis.Pass, f *ast.File) []Issue {
 		// 2. 【核心算法】：检查该协程是否伴随等待逻辑
 		// 逻辑：在当前的 BlockStmt（代码块）中，搜寻是否有 sync.WaitGroup 的踪迹
 		if !hasWaitMechanism(f, goStmt, pass.TypesInfo) {
-			start := pass.Fset.Position(goStmt.Pos())
-			end := pass.Fset.Position(goStmt.End())
-			content, _ := os.ReadFile(start.Filename)
-
 			issues = append(issues, Issue{
-				Pos:      goStmt.Pos(),
-				End:      goStmt.End(),
-				VarName:  "goroutine",
-				Snippet:  string(content[start.Offset:end.Offset]),
-				Message:  "⚠️ 发现未托管的 Goroutine：缺少 sync.WaitGroup 或 Context 控制，可能导致协程泄露",
-				Category: "GoroutineLeak",
+				Pos:        goStmt.Pos(),
+				End:        goStmt.End(),
+				VarName:    "goroutine",
+				Snippet:    Snippet(pass, goStmt),
+				Message:    "⚠️ 发现未托管的 Goroutine：缺少 sync.WaitGroup 或 Context 控制，可能导致协程泄露",
+				Category:   "GoroutineLeak",
+				Confidence: 0.4,
 			})
 		}
 		return true
@@ -5,7 +5,6 @@ import (
 	"go/ast"
 	"go/types"
 	"golang.org/x/tools/go/analysis"
-	"os"
 )
 
 func ScanResourceLeak(pass *analysis.Pass, f *ast.File) []Issue {
@@ -27,16 +26,14 @@ func ScanResourceLeak(pass *analysis.Pass, f *ast.File) []Issue {
 			typ := pass.TypesInfo.TypeOf(id)
 			if isCloser(typ) {
 				if !hasDeferClose(f, id, pass.TypesInfo) {
-					start := pass.Fset.Position(as.Pos())
-					end := pass.Fset.Position(as.End())
-					content, _ := os.ReadFile(start.Filename)
 					issues = append(issues, Issue{
-						Pos:      as.Pos(),
-						End:      as.End(),
-						VarName:  id.Name,
-						Snippet:  string(content[start.Offset:end.Offset]),
-						Message:  fmt.Sprintf("🚨 发现潜在资源泄露：变量 %s 未显式关闭", id.Name),
-						Category: "ResourceLeak",
+						Pos:        as.Pos(),
+						End:        as.End(),
+						VarName:    id.Name,
+						Snippet:    Snippet(pass, as),
+						Message:    fmt.Sprintf("🚨 发现潜在资源泄露：变量 %s 未显式关闭", id.Name),
+						Category:   "ResourceLeak",
+						Confidence: 0.8,
 					})
 				}
 			}
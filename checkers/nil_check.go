@@ -6,7 +6,6 @@ import (
 	"go/types"
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/ast/astutil"
-	"os"
 )
 
 func ScanNilPointer(pass *analysis.Pass, f *ast.File) []Issue {
@@ -40,17 +39,14 @@ func ScanNilPointer(pass *analysis.Pass, f *ast.File) []Issue {
 			// 2. 检查在此赋值语句后的代码块中，是否存在对 ptrId 的解引用（如 ptr.Field）
 			// 且这种解引用发生在对 errId 的 if 检查之前
 			if isRiskBeforeCheck(f, ptrId, errId, pass.TypesInfo) {
-				start := pass.Fset.Position(as.Pos())
-				end := pass.Fset.Position(as.End())
-				content, _ := os.ReadFile(start.Filename)
-
 				issues = append(issues, Issue{
-					Pos:      as.Pos(),
-					End:      as.End(),
-					VarName:  ptrId.Name,
-					Snippet:  string(content[start.Offset:end.Offset]),
-					Message:  fmt.Sprintf("🚨 空指针风险：在检查 %s 之前使用了可能为 nil 的变量 %s", errId.Name, ptrId.Name),
-					Category: "NilPointer",
+					Pos:        as.Pos(),
+					End:        as.End(),
+					VarName:    ptrId.Name,
+					Snippet:    Snippet(pass, as),
+					Message:    fmt.Sprintf("🚨 空指针风险：在检查 %s 之前使用了可能为 nil 的变量 %s", errId.Name, ptrId.Name),
+					Category:   "NilPointer",
+					Confidence: 0.8,
 				})
 			}
 		}
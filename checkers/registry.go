@@ -0,0 +1,199 @@
+package checkers
+
+import (
+	"fmt"
+	"go/ast"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// Checker 是所有缺陷检查器的统一抽象，引擎只通过它调度规则
+type Checker interface {
+	Name() string       // 规则名，用于配置文件与 --enable/--disable
+	Category() string   // 缺陷类别，与 Issue.Category 保持一致
+	Severity() Severity // 默认严重级别
+	Scan(pass *analysis.Pass, f *ast.File) []Issue
+}
+
+// Configurable 由支持自定义参数的检查器实现
+type Configurable interface {
+	Configure(settings map[string]interface{}) error
+}
+
+// ScanFunc 单文件扫描函数
+type ScanFunc func(pass *analysis.Pass, f *ast.File) []Issue
+
+type rule struct {
+	name      string
+	category  string
+	severity  Severity
+	scan      ScanFunc
+	configure func(settings map[string]interface{}) error
+}
+
+func (r *rule) Name() string       { return r.name }
+func (r *rule) Category() string   { return r.category }
+func (r *rule) Severity() Severity { return r.severity }
+
+func (r *rule) Scan(pass *analysis.Pass, f *ast.File) []Issue {
+	return r.scan(pass, f)
+}
+
+func (r *rule) Configure(settings map[string]interface{}) error {
+	if r.configure == nil {
+		return nil
+	}
+	return r.configure(settings)
+}
+
+// NewChecker 用一个扫描函数构造检查器，规则名取类别的小写形式
+func NewChecker(category string, severity Severity, scan ScanFunc) Checker {
+	return &rule{name: strings.ToLower(category), category: category, severity: severity, scan: scan}
+}
+
+// severityOverride 用配置中的级别覆盖检查器的默认级别
+type severityOverride struct {
+	Checker
+	severity Severity
+}
+
+func (s *severityOverride) Severity() Severity { return s.severity }
+
+var (
+	registryMu sync.RWMutex
+	registry   = builtinCheckers()
+)
+
+// builtinCheckers 内置规则，顺序即聚合时的优先顺序
+func builtinCheckers() []Checker {
+	return []Checker{
+		NewChecker("UnhandledError", SeverityWarning, ScanUnhandledError),
+		NewChecker("NilPointer", SeverityError, ScanNilPointer),
+		NewChecker("ResourceLeak", SeverityWarning, ScanResourceLeak),
+		&rule{name: "hardcodedsecret", category: "HardcodedSecret", severity: SeverityCritical,
+			scan: ScanHardcodedSecrets, configure: configureSecrets},
+		&rule{name: "sqlinjection", category: "SQLInjection", severity: SeverityCritical,
+			scan: ScanSQLInjection, configure: configureSQLInjection},
+		NewChecker("GoroutineLeak", SeverityWarning, ScanGoroutineLeak),
+	}
+}
+
+// Register 注册一个检查器，规则名或类别重复时 panic
+func Register(c Checker) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	for _, existing := range registry {
+		if matches(existing, c.Name()) || matches(existing, c.Category()) {
+			panic(fmt.Sprintf("checkers: 规则 %s 重复注册", c.Name()))
+		}
+	}
+	registry = append(registry, c)
+}
+
+// All 按注册顺序返回全部检查器
+func All() []Checker {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return append([]Checker(nil), registry...)
+}
+
+// Lookup 按规则名或类别查找检查器（大小写不敏感）
+func Lookup(key string) (Checker, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	for _, c := range registry {
+		if matches(c, key) {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+// Select 根据启用/禁用列表筛选检查器
+// enable 为空表示启用全部；同时出现在两个列表中的规则以 disable 为准
+func Select(enable, disable []string) ([]Checker, error) {
+	for _, key := range append(append([]string{}, enable...), disable...) {
+		if _, ok := Lookup(key); !ok {
+			return nil, fmt.Errorf("未知的检查器: %s", key)
+		}
+	}
+
+	var selected []Checker
+	for _, c := range All() {
+		if len(enable) > 0 && !matchesAny(c, enable) {
+			continue
+		}
+		if matchesAny(c, disable) {
+			continue
+		}
+		selected = append(selected, c)
+	}
+	return selected, nil
+}
+
+// ScanFile 用给定的检查器扫描单个文件：剔除被屏蔽指令覆盖的缺陷，
+// 为未填写的字段补上规则默认值，并汇报失效的屏蔽指令
+func ScanFile(pass *analysis.Pass, f *ast.File, active []Checker) []Issue {
+	dirs := parseDirectives(pass.Fset, f)
+	var issues []Issue
+	for _, c := range active {
+		for _, iss := range c.Scan(pass, f) {
+			if suppressed(dirs, c, iss) {
+				continue
+			}
+			if iss.Severity == "" {
+				iss.Severity = c.Severity()
+			}
+			if iss.Confidence == 0 {
+				iss.Confidence = 1
+			}
+			issues = append(issues, iss)
+		}
+	}
+	reportStaleDirectives(pass, dirs, active)
+	return issues
+}
+
+// Configure 将配置应用到指定检查器
+// 通用键 severity 覆盖默认级别，其余键交给检查器自身的 Configure 处理
+func Configure(key string, settings map[string]interface{}) error {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	for i, c := range registry {
+		if !matches(c, key) {
+			continue
+		}
+		if o, ok := c.(*severityOverride); ok {
+			c = o.Checker
+		}
+		if raw, ok := settings["severity"]; ok {
+			sev, err := ParseSeverity(fmt.Sprint(raw))
+			if err != nil {
+				return fmt.Errorf("检查器 %s: %w", c.Name(), err)
+			}
+			registry[i] = &severityOverride{Checker: c, severity: sev}
+		}
+		if cc, ok := c.(Configurable); ok {
+			if err := cc.Configure(settings); err != nil {
+				return fmt.Errorf("检查器 %s: %w", c.Name(), err)
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("未知的检查器: %s", key)
+}
+
+func matches(c Checker, key string) bool {
+	return strings.EqualFold(c.Name(), key) || strings.EqualFold(c.Category(), key)
+}
+
+func matchesAny(c Checker, keys []string) bool {
+	for _, key := range keys {
+		if matches(c, key) {
+			return true
+		}
+	}
+	return false
+}
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"go/ast"
 	"golang.org/x/tools/go/analysis"
-	"os"
 	"regexp"
 )
 
@@ -33,17 +32,14 @@ func ScanHardcodedSecrets(pass *analysis.Pass, f *ast.File) []Issue {
 					basic, ok := as.Rhs[i].(*ast.BasicLit)
 					// 排除空字符串，且长度大于一定阈值（比如秘钥通常较长）
 					if ok && len(basic.Value) > 5 {
-						start := pass.Fset.Position(as.Pos())
-						end := pass.Fset.Position(as.End())
-						content, _ := os.ReadFile(start.Filename)
-
 						issues = append(issues, Issue{
-							Pos:      as.Pos(),
-							End:      as.End(),
-							VarName:  id.Name,
-							Snippet:  string(content[start.Offset:end.Offset]),
-							Message:  fmt.Sprintf("🛡️ 安全风险：变量 '%s' 疑似包含硬编码秘钥，建议移至环境变量", id.Name),
-							Category: "HardcodedSecret",
+							Pos:        as.Pos(),
+							End:        as.End(),
+							VarName:    id.Name,
+							Snippet:    Snippet(pass, as),
+							Message:    fmt.Sprintf("🛡️ 安全风险：变量 '%s' 疑似包含硬编码秘钥，建议移至环境变量", id.Name),
+							Category:   "HardcodedSecret",
+							Confidence: 0.6,
 						})
 					}
 				}
@@ -53,3 +49,17 @@ func ScanHardcodedSecrets(pass *analysis.Pass, f *ast.File) []Issue {
 	})
 	return issues
 }
+
+// configureSecrets 支持通过 pattern 覆盖秘钥关键词正则
+func configureSecrets(settings map[string]interface{}) error {
+	raw, ok := settings["pattern"]
+	if !ok {
+		return nil
+	}
+	re, err := regexp.Compile(fmt.Sprint(raw))
+	if err != nil {
+		return fmt.Errorf("pattern 不是合法的正则: %w", err)
+	}
+	secretKeyRegex = re
+	return nil
+}
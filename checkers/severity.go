@@ -0,0 +1,45 @@
+package checkers
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Severity 缺陷严重级别
+type Severity string
+
+const (
+	SeverityInfo     Severity = "info"
+	SeverityWarning  Severity = "warning"
+	SeverityError    Severity = "error"
+	SeverityCritical Severity = "critical"
+)
+
+// ParseSeverity 将配置或命令行中的字符串解析为 Severity（大小写不敏感）
+func ParseSeverity(s string) (Severity, error) {
+	switch sev := Severity(strings.ToLower(strings.TrimSpace(s))); sev {
+	case SeverityInfo, SeverityWarning, SeverityError, SeverityCritical:
+		return sev, nil
+	}
+	return "", fmt.Errorf("未知的严重级别 %q (可选: info/warning/error/critical)", s)
+}
+
+var severityRank = map[Severity]int{
+	SeverityInfo:     1,
+	SeverityWarning:  2,
+	SeverityError:    3,
+	SeverityCritical: 4,
+}
+
+// AtLeast 判断 s 是否不低于 min；min 为空时总是成立
+func (s Severity) AtLeast(min Severity) bool {
+	return severityRank[s] >= severityRank[min]
+}
+
+// MaxSeverity 返回两者中更严重的一个
+func MaxSeverity(a, b Severity) Severity {
+	if severityRank[b] > severityRank[a] {
+		return b
+	}
+	return a
+}
@@ -1,10 +1,10 @@
 package checkers
 
 import (
+	"fmt"
 	"go/ast"
 	"go/types"
 	"golang.org/x/tools/go/analysis"
-	"os"
 )
 
 var dbMethodRegex = map[string]bool{
@@ -31,17 +31,14 @@ func ScanSQLInjection(pass *analysis.Pass, f *ast.File) []Issue {
 
 				// 2. 【核心升级】：不仅检查表达式，还检查变量来源
 				if isTainted(firstArg, pass.TypesInfo) {
-					start := pass.Fset.Position(call.Pos())
-					end := pass.Fset.Position(call.End())
-					content, _ := os.ReadFile(start.Filename)
-
 					issues = append(issues, Issue{
-						Pos:      call.Pos(),
-						End:      call.End(),
-						VarName:  sel.Sel.Name,
-						Snippet:  string(content[start.Offset:end.Offset]),
-						Message:  "🛡️ SQL 注入风险：检测到污点变量流入数据库查询，请使用参数化查询改写",
-						Category: "SQLInjection",
+						Pos:        call.Pos(),
+						End:        call.End(),
+						VarName:    sel.Sel.Name,
+						Snippet:    Snippet(pass, call),
+						Message:    "🛡️ SQL 注入风险：检测到污点变量流入数据库查询，请使用参数化查询改写",
+						Category:   "SQLInjection",
+						Confidence: 0.9,
 					})
 				}
 			}
@@ -105,3 +102,27 @@ func isDangerousSQLString(expr ast.Expr) bool {
 	}
 	return false
 }
+
+// configureSQLInjection 支持通过 methods 覆盖需要检查的数据库方法名
+func configureSQLInjection(settings map[string]interface{}) error {
+	raw, ok := settings["methods"]
+	if !ok {
+		return nil
+	}
+	list, ok := raw.([]interface{})
+	if !ok {
+		if ss, isStrings := raw.([]string); isStrings {
+			for _, s := range ss {
+				list = append(list, s)
+			}
+		} else {
+			return fmt.Errorf("methods 必须是字符串列表")
+		}
+	}
+	methods := make(map[string]bool, len(list))
+	for _, m := range list {
+		methods[fmt.Sprint(m)] = true
+	}
+	dbMethodRegex = methods
+	return nil
+}
@@ -0,0 +1,169 @@
+package checkers
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// StaleSuppression 失效屏蔽指令的诊断类别
+const StaleSuppression = "StaleSuppression"
+
+// 屏蔽指令：
+//
+//	//golint-ai:ignore HardcodedSecret -- 测试夹具
+//	//golint-ai:file-ignore GoroutineLeak,NilPointer -- 整个文件都不检查
+//
+// ignore 写在代码行末尾时作用于该行开始的语句，单独成行时作用于所在注释块之后下一行开始的语句或声明
+// （写在 func、if、for 上方即屏蔽整个块，与声明之间可以隔着文档注释）；file-ignore 作用于整个文件。
+// 规则列表可写规则名或类别，用逗号分隔，省略表示全部规则；不存在的规则名会被报告。
+const (
+	ignoreDirective     = "//golint-ai:ignore"
+	fileIgnoreDirective = "//golint-ai:file-ignore"
+)
+
+type directive struct {
+	pos        token.Pos
+	rules      []string // 为空表示全部规则
+	start, end token.Pos
+	used       map[string]bool // 已命中的规则名
+}
+
+// covers 判断指令是否屏蔽规则 c 在 pos 处的缺陷
+func (d *directive) covers(c Checker, pos token.Pos) bool {
+	if pos < d.start || pos >= d.end {
+		return false
+	}
+	return len(d.rules) == 0 || matchesAny(c, d.rules)
+}
+
+// parseDirectives 收集文件中的屏蔽指令并计算各自的作用范围
+func parseDirectives(fset *token.FileSet, f *ast.File) []*directive {
+	var dirs []*directive
+	for _, cg := range f.Comments {
+		for _, c := range cg.List {
+			var fileScope bool
+			var body string
+			switch {
+			case strings.HasPrefix(c.Text, fileIgnoreDirective):
+				fileScope, body = true, strings.TrimPrefix(c.Text, fileIgnoreDirective)
+			case strings.HasPrefix(c.Text, ignoreDirective):
+				body = strings.TrimPrefix(c.Text, ignoreDirective)
+			default:
+				continue
+			}
+			if body != "" && body[0] != ' ' && body[0] != '\t' {
+				continue // 形如 //golint-ai:ignored 的其他注释
+			}
+			if i := strings.Index(body, "--"); i >= 0 {
+				body = body[:i] // 去掉原因说明
+			}
+
+			d := &directive{pos: c.Pos(), used: make(map[string]bool)}
+			for _, r := range strings.FieldsFunc(body, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+				if !strings.EqualFold(r, "all") {
+					d.rules = append(d.rules, r)
+				}
+			}
+			if fileScope {
+				d.start, d.end = f.FileStart, f.FileEnd
+			} else {
+				d.start, d.end = directiveScope(fset, f, cg, c)
+			}
+			dirs = append(dirs, d)
+		}
+	}
+	return dirs
+}
+
+// directiveScope 计算行级/块级指令的作用范围：目标行上开始的所有节点的并集。
+// 单独成行的指令以所在注释块（cg）之后的一行为目标行，因此写在文档注释上方也能屏蔽其后的声明
+func directiveScope(fset *token.FileSet, f *ast.File, cg *ast.CommentGroup, c *ast.Comment) (token.Pos, token.Pos) {
+	line := fset.Position(c.Pos()).Line
+	trailing := false
+	ast.Inspect(f, func(n ast.Node) bool {
+		if n == nil || trailing {
+			return false
+		}
+		if _, ok := n.(*ast.File); !ok && n.Pos() < c.Pos() && fset.Position(n.Pos()).Line == line {
+			trailing = true
+		}
+		return true
+	})
+	if !trailing {
+		line = fset.Position(cg.End()).Line + 1
+	}
+
+	var start, end token.Pos
+	ast.Inspect(f, func(n ast.Node) bool {
+		if n == nil {
+			return false
+		}
+		switch n.(type) {
+		case *ast.File, *ast.CommentGroup, *ast.Comment:
+			return true
+		}
+		if fset.Position(n.Pos()).Line != line {
+			return true
+		}
+		if start == token.NoPos || n.Pos() < start {
+			start = n.Pos()
+		}
+		if n.End() > end {
+			end = n.End()
+		}
+		return true
+	})
+	return start, end
+}
+
+// suppressed 判断缺陷是否被某条指令屏蔽，并记录命中情况
+func suppressed(dirs []*directive, c Checker, iss Issue) bool {
+	hit := false
+	for _, d := range dirs {
+		if d.covers(c, iss.Pos) {
+			d.used[c.Name()] = true
+			hit = true
+		}
+	}
+	return hit
+}
+
+// reportStaleDirectives 汇报本次运行的规则中没有屏蔽任何缺陷的指令，避免屏蔽注释腐烂
+func reportStaleDirectives(pass *analysis.Pass, dirs []*directive, active []Checker) {
+	for _, d := range dirs {
+		if len(d.rules) == 0 {
+			if len(d.used) == 0 && len(active) == len(All()) {
+				reportStale(pass, d, "")
+			}
+			continue
+		}
+		for _, r := range d.rules {
+			if _, ok := Lookup(r); !ok {
+				reportUnknown(pass, d, r)
+				continue
+			}
+			for _, c := range active {
+				if matches(c, r) && !d.used[c.Name()] {
+					reportStale(pass, d, c.Category())
+				}
+			}
+		}
+	}
+}
+
+func reportUnknown(pass *analysis.Pass, d *directive, rule string) {
+	pass.Report(analysis.Diagnostic{Pos: d.pos, Category: StaleSuppression,
+		Message: fmt.Sprintf("golint-ai 屏蔽指令中的规则 %s 不存在", rule)})
+}
+
+func reportStale(pass *analysis.Pass, d *directive, category string) {
+	msg := "golint-ai 屏蔽指令没有屏蔽任何缺陷，请删除"
+	if category != "" {
+		msg = fmt.Sprintf("golint-ai 屏蔽指令没有屏蔽任何 %s 缺陷，请删除", category)
+	}
+	pass.Report(analysis.Diagnostic{Pos: d.pos, Category: StaleSuppression, Message: msg})
+}
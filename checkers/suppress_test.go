@@ -0,0 +1,189 @@
+package checkers
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"sort"
+	"testing"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+func parse(t *testing.T, src string) (*token.FileSet, *ast.File) {
+	t.Helper()
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "a.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fset, f
+}
+
+func TestParseDirectives(t *testing.T) {
+	tests := []struct {
+		name       string
+		src        string
+		rules      [][]string // 每条指令的规则，nil 表示全部规则
+		start, end int        // 第一条指令作用范围的起止行，0 表示整个文件
+	}{
+		{
+			name:  "行尾指令作用于所在行",
+			src:   "package demo\n\nfunc A() {\n\tx := 1 //golint-ai:ignore HardcodedSecret -- 测试夹具\n\t_ = x\n}\n",
+			rules: [][]string{{"HardcodedSecret"}},
+			start: 4, end: 4,
+		},
+		{
+			name:  "单独成行时作用于下一条语句",
+			src:   "package demo\n\nfunc A() {\n\t//golint-ai:ignore NilPointer\n\tx := 1\n\t_ = x\n}\n",
+			rules: [][]string{{"NilPointer"}},
+			start: 5, end: 5,
+		},
+		{
+			name:  "写在 if 上方屏蔽整个块",
+			src:   "package demo\n\nfunc A(x int) {\n\t//golint-ai:ignore GoroutineLeak\n\tif x > 0 {\n\t\tx++\n\t}\n}\n",
+			rules: [][]string{{"GoroutineLeak"}},
+			start: 5, end: 7,
+		},
+		{
+			name:  "写在文档注释上方时屏蔽其后的声明",
+			src:   "package demo\n\n//golint-ai:ignore ResourceLeak -- 由调用方关闭\n// A 打开文件\nfunc A() {\n}\n",
+			rules: [][]string{{"ResourceLeak"}},
+			start: 5, end: 6,
+		},
+		{
+			name:  "连续的多条指令作用于同一条语句",
+			src:   "package demo\n\nfunc A() {\n\t//golint-ai:ignore NilPointer\n\t//golint-ai:ignore UnhandledError\n\tx := 1\n\t_ = x\n}\n",
+			rules: [][]string{{"NilPointer"}, {"UnhandledError"}},
+			start: 6, end: 6,
+		},
+		{
+			name:  "file-ignore 作用于整个文件",
+			src:   "package demo\n\n//golint-ai:file-ignore GoroutineLeak,NilPointer -- 整个文件都不检查\n\nfunc A() {}\n",
+			rules: [][]string{{"GoroutineLeak", "NilPointer"}},
+		},
+		{
+			name:  "-- 之后的原因不计入规则",
+			src:   "package demo\n\nvar x = 1 //golint-ai:ignore NilPointer, ResourceLeak -- 原因里也有, 逗号 all\n",
+			rules: [][]string{{"NilPointer", "ResourceLeak"}},
+			start: 3, end: 3,
+		},
+		{
+			name:  "省略规则或写 all 表示全部规则",
+			src:   "package demo\n\nvar x = 1 //golint-ai:ignore -- 原因\nvar y = 2 //golint-ai:ignore all\n",
+			rules: [][]string{nil, nil},
+			start: 3, end: 3,
+		},
+		{
+			name:  "前缀相同的其他注释不是指令",
+			src:   "package demo\n\nvar x = 1 //golint-ai:ignored NilPointer\n",
+			rules: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fset, f := parse(t, tt.src)
+			dirs := parseDirectives(fset, f)
+			var rules [][]string
+			for _, d := range dirs {
+				rules = append(rules, d.rules)
+			}
+			if !reflect.DeepEqual(rules, tt.rules) {
+				t.Fatalf("规则 = %q, want %q", rules, tt.rules)
+			}
+			if len(dirs) == 0 {
+				return
+			}
+			d := dirs[0]
+			if tt.start == 0 {
+				if d.start != f.FileStart || d.end != f.FileEnd {
+					t.Fatalf("file-ignore 的作用范围 = [%d,%d), want 整个文件", d.start, d.end)
+				}
+				return
+			}
+			if d.start == token.NoPos {
+				t.Fatal("指令没有作用于任何代码")
+			}
+			start, end := fset.Position(d.start).Line, fset.Position(d.end).Line
+			if start != tt.start || end != tt.end {
+				t.Fatalf("作用范围 = 第 %d-%d 行, want 第 %d-%d 行", start, end, tt.start, tt.end)
+			}
+		})
+	}
+}
+
+// lineChecker 构造一个在指定行首条语句处报告缺陷的检查器
+func lineChecker(category string, fset *token.FileSet, lines ...int) Checker {
+	return NewChecker(category, SeverityWarning, func(pass *analysis.Pass, f *ast.File) []Issue {
+		tf := fset.File(f.Pos())
+		var issues []Issue
+		for _, line := range lines {
+			pos := tf.LineStart(line) + 1 // 跳过缩进的制表符
+			issues = append(issues, Issue{Pos: pos, End: pos + 1, Category: category})
+		}
+		return issues
+	})
+}
+
+// scan 用 active 扫描文件，返回未被屏蔽的缺陷所在行与失效指令的诊断（行号: 提示）
+func scan(fset *token.FileSet, f *ast.File, active []Checker) ([]int, []string) {
+	var stale []string
+	pass := &analysis.Pass{Fset: fset, Report: func(d analysis.Diagnostic) {
+		stale = append(stale, fset.Position(d.Pos).String()+": "+d.Message)
+	}}
+	var lines []int
+	for _, iss := range ScanFile(pass, f, active) {
+		lines = append(lines, fset.Position(iss.Pos).Line)
+	}
+	sort.Strings(stale)
+	return lines, stale
+}
+
+func TestReportStaleDirectives(t *testing.T) {
+	src := `package demo
+
+func A() {
+	a := 1 //golint-ai:ignore HardcodedSecret -- 命中
+	b := 2 //golint-ai:ignore NilPointer -- 没有命中
+	c := 3 //golint-ai:ignore NoSuchRule
+	d := 4 //golint-ai:ignore GoroutineLeak -- 规则未启用，不汇报
+	e := 5
+	_, _, _, _, _ = a, b, c, d, e
+}
+`
+	fset, f := parse(t, src)
+	active := []Checker{lineChecker("HardcodedSecret", fset, 4, 8), lineChecker("NilPointer", fset)}
+	lines, stale := scan(fset, f, active)
+	if !reflect.DeepEqual(lines, []int{8}) {
+		t.Errorf("未被屏蔽的缺陷在第 %v 行, want [8]", lines)
+	}
+	want := []string{
+		"a.go:5:9: golint-ai 屏蔽指令没有屏蔽任何 NilPointer 缺陷，请删除",
+		"a.go:6:9: golint-ai 屏蔽指令中的规则 NoSuchRule 不存在",
+	}
+	if !reflect.DeepEqual(stale, want) {
+		t.Errorf("失效指令的诊断 = %q, want %q", stale, want)
+	}
+}
+
+// 不带规则的指令只有在全部规则都启用时才能判断是否失效
+func TestReportStaleDirectivesAllRules(t *testing.T) {
+	src := "package demo\n\nfunc A() {\n\tx := 1 //golint-ai:ignore -- 原因\n\t_ = x\n}\n"
+	fset, f := parse(t, src)
+	var all []Checker
+	for _, c := range All() {
+		all = append(all, lineChecker(c.Category(), fset))
+	}
+
+	if _, stale := scan(fset, f, all); len(stale) != 1 {
+		t.Errorf("全部规则启用时的诊断 = %q, want 1 条", stale)
+	}
+	if _, stale := scan(fset, f, all[:1]); len(stale) != 0 {
+		t.Errorf("只启用部分规则时不应汇报: %q", stale)
+	}
+	all[0] = lineChecker(all[0].Category(), fset, 4)
+	if lines, stale := scan(fset, f, all); len(lines) != 0 || len(stale) != 0 {
+		t.Errorf("指令屏蔽了缺陷: 剩余缺陷 %v, 诊断 %q", lines, stale)
+	}
+}
@@ -0,0 +1,41 @@
+// golint-ai-fix 以 analysis 驱动的方式运行 golint-ai 的 AI 修复引擎：
+// 每条诊断都附带经过编译与复查校验的 SuggestedFix（含补丁需要的导入变化），可以用 -diff / -fix 直接预览或应用。
+//
+// 用法：
+//
+//	go install github.com/hsdaoqi/golint-ai/cmd/golint-ai-fix
+//	golint-ai-fix ./...         # 只打印诊断
+//	golint-ai-fix -diff ./...   # 以 unified diff 预览所有修复
+//	golint-ai-fix -fix ./...    # 把所有修复写回源文件
+//
+// 与 golint-ai fix 不同，这里没有交互确认：fix.policy 为 never 的类别不附带修复，其余类别（包括 ask）全部应用，
+// 适合在 CI 中一次性应用；写回的文件同样记录在修复日志中，可用 golint-ai undo 撤销。
+// 需要与 golint-ai 相同的 configs/config.yaml（或 GOLINT_ 开头的环境变量）来访问 LLM。
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/hsdaoqi/golint-ai/pkg/runner"
+)
+
+var (
+	diff = flag.Bool("diff", false, "以 unified diff 打印所有修复，不修改文件")
+	fix  = flag.Bool("fix", false, "把所有修复写回源文件")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "用法: golint-ai-fix [-diff] [-fix] package...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(runner.ExitError)
+	}
+	// 退出码由 ApplySuggested 返回，返回前已清理校验沙箱
+	os.Exit(runner.ApplySuggested(flag.Args(), *diff, *fix))
+}
@@ -0,0 +1,18 @@
+// golint-ai-vet 只运行 golint-ai 的检测规则，不调用 AI，也不需要 API Key。
+//
+// 用法：
+//
+//	go install github.com/hsdaoqi/golint-ai/cmd/golint-ai-vet
+//	go vet -vettool=$(which golint-ai-vet) ./...
+//
+// 也可以直接运行 golint-ai-vet ./...，并通过 -<规则名>=false 关闭单条规则。
+package main
+
+import (
+	"github.com/hsdaoqi/golint-ai/checkers"
+	"golang.org/x/tools/go/analysis/multichecker"
+)
+
+func main() {
+	multichecker.Main(checkers.Analyzers()...)
+}
@@ -2,11 +2,31 @@ package main
 
 import (
 	"fmt"
+	"github.com/hsdaoqi/golint-ai/checkers"
 	"github.com/hsdaoqi/golint-ai/pkg/analyzer"
+	"github.com/hsdaoqi/golint-ai/pkg/baseline"
 	"github.com/hsdaoqi/golint-ai/pkg/config"
+	"github.com/hsdaoqi/golint-ai/pkg/gitcommit"
+	"github.com/hsdaoqi/golint-ai/pkg/gitdiff"
+	"github.com/hsdaoqi/golint-ai/pkg/journal"
+	"github.com/hsdaoqi/golint-ai/pkg/report"
+	"github.com/hsdaoqi/golint-ai/pkg/runner"
 	"github.com/spf13/cobra"
-	"golang.org/x/tools/go/analysis/singlechecker"
 	"os"
+	"strings"
+	"time"
+)
+
+var (
+	minSeverity  string
+	failOn       string
+	baselineFile string
+	baselineOut  string
+	newFromRev   string
+	newFromPatch string
+	showDiff     bool
+	patchOut     string
+	listSessions bool
 )
 
 var rootCmd = &cobra.Command{
@@ -19,29 +39,221 @@ var scanCmd = &cobra.Command{
 	Use:   "scan [path]",
 	Short: "仅扫描代码并给出修复建议",
 	Args:  cobra.MinimumNArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := parseSeverityFlags(); err != nil {
+			return err
+		}
+		if err := parseFailOn(); err != nil {
+			return err
+		}
+		if err := loadChangedLines(); err != nil {
+			return err
+		}
+		if err := checkFormat(runner.Format); err != nil {
+			return err
+		}
+		if baselineFile != "" {
+			b, err := baseline.Load(baselineFile)
+			if err != nil {
+				return err
+			}
+			analyzer.Baseline = b
+		}
 		analyzer.FixMode = false // 设置为非修复模式
-		os.Args = append([]string{os.Args[0]}, args...)
-		singlechecker.Main(analyzer.Analyzer)
+		os.Exit(runner.Run(args))
+		return nil
 	},
 }
 
-// fix 模式：发现问题后询问用户是否写入
+// fix 模式：发现问题后询问用户是否写入，--yes / --dry-run 时按策略批量处理
 var fixCmd = &cobra.Command{
 	Use:   "fix [path]",
 	Short: "交互式扫描并修复代码",
 	Args:  cobra.MinimumNArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		analyzer.FixMode = true // 开启修复模式
-		os.Args = append([]string{os.Args[0]}, args...)
-		singlechecker.Main(analyzer.Analyzer)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !analyzer.AIEnabled() {
+			return fmt.Errorf("fix 模式依赖 AI 生成补丁，请在配置中开启 ai.enabled")
+		}
+		if err := parseSeverityFlags(); err != nil {
+			return err
+		}
+		if err := loadChangedLines(); err != nil {
+			return err
+		}
+		if err := analyzer.ValidatePolicy(); err != nil {
+			return err
+		}
+		// 发现的缺陷交由修复会话处理，fix 只在出错时以非零退出码结束
+		runner.FailOn = ""
+		if analyzer.MaxFixes < 0 {
+			return fmt.Errorf("--max-fixes 不能为负数")
+		}
+		if analyzer.GitCommit || analyzer.GitBranch != "" {
+			if showDiff || patchOut != "" {
+				return fmt.Errorf("--git-commit/--git-branch 不能与 --diff 或 --patch-out 同时使用")
+			}
+			if _, err := gitdiff.Root(); err != nil {
+				return fmt.Errorf("--git-commit/--git-branch 需要在 git 仓库中运行: %w", err)
+			}
+			// 提前检查，免得请求完 AI、写入文件之后才发现无法提交
+			if err := gitcommit.CheckIdentity(); err != nil {
+				return err
+			}
+		}
+		// 开启修复模式：--only-categories 与 fix.policy 在请求 AI 之前生效，预览模式也不例外
+		analyzer.FixMode = true
+		if showDiff || patchOut != "" {
+			// 预览模式：只收集补丁并输出 diff，不交互、不写文件
+			analyzer.DryRun = true
+			os.Exit(runner.Diff(args, showDiff, patchOut))
+		}
+		os.Exit(runner.Run(args))
+		return nil
+	},
+}
+
+// baseline 模式：记录现有缺陷，之后的 scan 只汇报新增缺陷
+var baselineCmd = &cobra.Command{
+	Use:   "baseline",
+	Short: "管理缺陷基线文件",
+}
+
+var baselineWriteCmd = &cobra.Command{
+	Use:   "write [path]",
+	Short: "把当前所有缺陷记录到基线文件",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		analyzer.NoAI = true // 记录基线只需要检测结果
+		return runner.WriteBaseline(args, baselineOut)
 	},
 }
 
+// undo 模式：根据修复日志把一次 fix 会话写入的文件恢复原状
+var undoCmd = &cobra.Command{
+	Use:   "undo [session]",
+	Short: "撤销一次修复会话（默认最近一次）",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if listSessions {
+			return printSessions()
+		}
+		var s *journal.Session
+		var err error
+		if len(args) == 1 {
+			s, err = journal.Load(args[0])
+		} else {
+			s, err = journal.Latest()
+		}
+		if err != nil {
+			return err
+		}
+		if err := s.Undo(); err != nil {
+			return err
+		}
+		fmt.Printf("已撤销会话 %s，恢复了 %d 个文件\n", s.ID, len(s.Files))
+		return nil
+	},
+}
+
+// printSessions 列出修复日志中的所有会话
+func printSessions() error {
+	sessions, err := journal.List()
+	if err != nil {
+		return err
+	}
+	for _, s := range sessions {
+		state := ""
+		if s.Undone != nil {
+			state = " (已撤销)"
+		}
+		fmt.Printf("%s  %s  %d 个文件%s\n", s.ID, s.Time.Format(time.DateTime), len(s.Files), state)
+		for _, fc := range s.Files {
+			fmt.Printf("    %s: %s\n", fc.Path, strings.Join(fc.Fixes, ", "))
+		}
+	}
+	return nil
+}
+
 func init() {
 	config.Load()
+	scanCmd.Flags().StringVar(&failOn, "fail-on", "info", "存在不低于该级别的缺陷时以退出码 3 结束 (info/warning/error/critical/none)")
+	scanCmd.Flags().StringVar(&runner.Format, "format", report.FormatText, "报告格式 (text/json/sarif/checkstyle/junit)")
+	scanCmd.Flags().StringVarP(&runner.Output, "output", "o", "", "报告写入的文件，默认输出到标准输出")
+	scanCmd.Flags().StringVar(&baselineFile, "baseline", "", "只汇报不在该基线文件中的新缺陷")
+	fixCmd.Flags().BoolVar(&showDiff, "diff", false, "以 unified diff 打印所有修复，不修改文件")
+	fixCmd.Flags().StringVar(&patchOut, "patch-out", "", "把所有修复写成可供 git apply 使用的补丁文件，不修改源文件")
+	fixCmd.Flags().BoolVarP(&analyzer.AssumeYes, "yes", "y", false, "不再询问，按 fix.policy 自动应用所有允许的修复")
+	fixCmd.Flags().BoolVar(&analyzer.DryRun, "dry-run", false, "按策略演练一遍并打印汇总，不修改文件")
+	fixCmd.Flags().StringSliceVar(&analyzer.OnlyCategories, "only-categories", nil, "只修复指定类别的缺陷 (逗号分隔)")
+	fixCmd.Flags().BoolVar(&analyzer.VerifyTests, "verify-tests", false, "补丁还需通过所在包的测试，补丁前通过的测试失败时拒绝该补丁（测试以当前用户身份运行 AI 修改后的代码，没有安全隔离）")
+	fixCmd.Flags().BoolVar(&analyzer.TestOptions.Race, "race", false, "配合 --verify-tests，以 -race 运行测试")
+	fixCmd.Flags().DurationVar(&analyzer.TestOptions.Timeout, "test-timeout", 5*time.Minute, "配合 --verify-tests，单次 go test 的超时")
+	fixCmd.Flags().BoolVar(&analyzer.FuncScope, "func-scope", false, "按函数批量修复：同一函数中的缺陷一次性交给 AI，整个函数体作为一个补丁替换")
+	fixCmd.Flags().BoolVar(&analyzer.GitCommit, "git-commit", false, "每个修复单独生成一个本地 git 提交")
+	fixCmd.Flags().StringVar(&analyzer.GitBranch, "git-branch", "", "先创建并切换到该分支，再把每个修复单独提交 (隐含 --git-commit)")
+	fixCmd.Flags().IntVar(&analyzer.MaxFixes, "max-fixes", 0, "最多应用的修复数，0 表示不限")
+	undoCmd.Flags().BoolVar(&listSessions, "list", false, "列出所有修复会话")
+	baselineWriteCmd.Flags().StringVarP(&baselineOut, "file", "f", ".golint-ai-baseline.json", "基线文件路径")
+	scanCmd.Flags().BoolVar(&analyzer.NoAI, "no-ai", false, "只做检测，不请求 LLM 生成修复建议")
+	for _, cmd := range []*cobra.Command{scanCmd, fixCmd} {
+		cmd.Flags().StringSliceVar(&analyzer.Enable, "enable", nil, "只启用指定的检查器 (逗号分隔)")
+		cmd.Flags().StringSliceVar(&analyzer.Disable, "disable", nil, "禁用指定的检查器 (逗号分隔)")
+		cmd.Flags().StringVar(&newFromRev, "new-from-rev", "", "只处理相对该 git 版本发生变更的代码行中的缺陷")
+		cmd.Flags().StringVar(&newFromPatch, "new-from-patch", "", "只处理该 unified diff 补丁所变更代码行中的缺陷")
+		cmd.Flags().StringVar(&minSeverity, "min-severity", "info", "只处理不低于该级别的缺陷 (info/warning/error/critical)")
+	}
 	rootCmd.AddCommand(scanCmd)
 	rootCmd.AddCommand(fixCmd)
+	baselineCmd.AddCommand(baselineWriteCmd)
+	rootCmd.AddCommand(baselineCmd)
+	rootCmd.AddCommand(undoCmd)
+}
+
+// parseSeverityFlags 校验并应用 --min-severity
+func parseSeverityFlags() error {
+	sev, err := checkers.ParseSeverity(minSeverity)
+	if err != nil {
+		return fmt.Errorf("--min-severity: %w", err)
+	}
+	analyzer.MinSeverity = sev
+	return nil
+}
+
+// parseFailOn 校验并应用 scan 的 --fail-on
+func parseFailOn() error {
+	if failOn == "none" {
+		runner.FailOn = ""
+		return nil
+	}
+	var err error
+	if runner.FailOn, err = checkers.ParseSeverity(failOn); err != nil {
+		return fmt.Errorf("--fail-on: %w", err)
+	}
+	return nil
+}
+
+// checkFormat 在开始分析前校验 --format，避免跑完才发现格式写错
+func checkFormat(format string) error {
+	for _, f := range report.Formats() {
+		if f == format {
+			return nil
+		}
+	}
+	return fmt.Errorf("--format: 不支持的格式 %q", format)
+}
+
+// loadChangedLines 根据 --new-from-rev / --new-from-patch 读取变更行
+func loadChangedLines() error {
+	var err error
+	switch {
+	case newFromRev != "" && newFromPatch != "":
+		return fmt.Errorf("--new-from-rev 与 --new-from-patch 不能同时使用")
+	case newFromRev != "":
+		analyzer.ChangedLines, err = gitdiff.FromRev(newFromRev)
+	case newFromPatch != "":
+		analyzer.ChangedLines, err = gitdiff.FromPatch(newFromPatch)
+	}
+	return err
 }
 
 func main() {
@@ -3,6 +3,7 @@ module github.com/hsdaoqi/golint-ai
 go 1.24.11
 
 require (
+	github.com/golangci/plugin-module-register v0.1.2
 	github.com/spf13/cobra v1.10.2
 	github.com/spf13/viper v1.21.0
 	golang.org/x/tools v0.42.0
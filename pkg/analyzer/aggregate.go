@@ -0,0 +1,222 @@
+package analyzer
+
+import (
+	"fmt"
+	"go/token"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/hsdaoqi/golint-ai/checkers"
+	"github.com/hsdaoqi/golint-ai/pkg/verifier"
+	"golang.org/x/tools/go/analysis"
+)
+
+// aggregate 把区间重叠或嵌套的缺陷聚合为一处，保证交给 AI 的各个区间互不相交，逆序拼接补丁时不会互相覆盖
+// functions 与 fingerprints 与 issues 一一对应，content 为 issues 所在文件的内容，用于读取合并后区间的代码片段
+func aggregate(fset *token.FileSet, content []byte, issues []checkers.Issue, functions, fingerprints []string) []*AggregatedIssue {
+	order := make([]int, len(issues))
+	for i := range order {
+		order[i] = i
+	}
+	// 起点相同时较长的区间在前，嵌套在其中的缺陷随后并入
+	sort.SliceStable(order, func(a, b int) bool {
+		ia, ib := issues[order[a]], issues[order[b]]
+		if ia.Pos != ib.Pos {
+			return ia.Pos < ib.Pos
+		}
+		return ia.End > ib.End
+	})
+
+	var list []*AggregatedIssue
+	var cur *AggregatedIssue
+	for _, i := range order {
+		iss := issues[i]
+		if cur != nil && (iss.Pos < cur.End || iss.Pos == cur.Pos) {
+			cur.add(iss, fingerprints[i])
+			if iss.End > cur.End {
+				cur.End = iss.End
+				cur.Snippet = snippet(fset, content, cur.Pos, cur.End, cur.Snippet)
+			}
+			continue
+		}
+		cur = &AggregatedIssue{
+			Pos:        iss.Pos,
+			End:        iss.End,
+			VarName:    iss.VarName,
+			Snippet:    iss.Snippet,
+			Categories: []string{iss.Category},
+			Messages:   []string{iss.Message},
+			Filename:   fset.Position(iss.Pos).Filename,
+			Severity:   iss.Severity,
+			Confidence: iss.Confidence,
+
+			Function:     functions[i],
+			Fingerprints: []string{fingerprints[i]},
+		}
+		list = append(list, cur)
+	}
+	return list
+}
+
+// add 把一条缺陷并入聚合结果，级别与置信度取较高者
+func (a *AggregatedIssue) add(iss checkers.Issue, fingerprint string) {
+	a.Categories = append(a.Categories, iss.Category)
+	a.Messages = append(a.Messages, iss.Message)
+	a.Fingerprints = append(a.Fingerprints, fingerprint)
+	a.Severity = checkers.MaxSeverity(a.Severity, iss.Severity)
+	if iss.Confidence > a.Confidence {
+		a.Confidence = iss.Confidence
+	}
+	a.addVarName(iss.VarName)
+}
+
+func (a *AggregatedIssue) addVarName(name string) {
+	if name == "" {
+		return
+	}
+	for _, existing := range strings.Split(a.VarName, ", ") {
+		if existing == name {
+			return
+		}
+	}
+	if a.VarName == "" {
+		a.VarName = name
+	} else {
+		a.VarName += ", " + name
+	}
+}
+
+// mergeIssues 返回覆盖 a、b 两处缺陷的新聚合结果，不修改 a 与 b；content 为所在文件的内容
+func mergeIssues(fset *token.FileSet, content []byte, a, b *AggregatedIssue) *AggregatedIssue {
+	if b.Pos < a.Pos {
+		a, b = b, a
+	}
+	m := *a
+	m.Categories = append(append([]string{}, a.Categories...), b.Categories...)
+	m.Messages = append(append([]string{}, a.Messages...), b.Messages...)
+	m.Fingerprints = append(append([]string{}, a.Fingerprints...), b.Fingerprints...)
+	m.Severity = checkers.MaxSeverity(a.Severity, b.Severity)
+	m.Confidence = max(a.Confidence, b.Confidence)
+	for _, name := range strings.Split(b.VarName, ", ") {
+		m.addVarName(name)
+	}
+	m.End = max(a.End, b.End)
+	m.Snippet = snippet(fset, content, m.Pos, m.End, a.Snippet)
+	return &m
+}
+
+// snippet 返回文件内容 content 中 [pos, end) 的源码，区间超出 content 时返回 fallback。
+// content 应来自 pass.ReadFile 等与 fset 一致的来源，而不是直接读磁盘，否则 overlay 中的文件会错位
+func snippet(fset *token.FileSet, content []byte, pos, end token.Pos, fallback string) string {
+	start, stop := fset.Position(pos).Offset, fset.Position(end).Offset
+	if start < 0 || stop > len(content) || start > stop {
+		return fallback
+	}
+	return string(content[start:stop])
+}
+
+// combineFixes 校验同一文件中的全部补丁合在一起能否通过编译：各补丁单独校验时都能编译，
+// 合在一起却可能冲突，例如两处修复都声明了 var wg sync.WaitGroup 或 err :=。
+// 不能编译时按级别从高到低逐个加入，加入后无法编译的补丁在 merge 为 true 时先与同一函数中已保留的补丁
+// 合并为一个缺陷重新申请修复，仍不行就放弃它并记为失败（保留级别更高的修复）。
+// 返回处理后的结果：被合并的结果不再单独出现，不合并时结果与 fixes 一一对应。content 为 filename 修复前的内容
+func combineFixes(fset *token.FileSet, filename string, content []byte, fixes []FixResult, merge bool) []FixResult {
+	var order []int
+	for i, res := range fixes {
+		if res.Error == nil && len(res.Edits) > 0 {
+			order = append(order, i)
+		}
+	}
+	if len(order) < 2 {
+		return fixes
+	}
+	compiles := func(results []FixResult, indices []int) (bool, string) {
+		var edits []analysis.TextEdit
+		for _, i := range indices {
+			edits = append(edits, results[i].Edits...)
+		}
+		newContent, err := FixFile(fset, filename, content, edits)
+		if err != nil {
+			return false, err.Error()
+		}
+		vr, err := verifier.ValidatePatch(filename, newContent)
+		if err != nil {
+			return false, err.Error()
+		}
+		if !vr.OK() {
+			return false, verifier.FormatErrors(vr.Errors)
+		}
+		return true, ""
+	}
+	if ok, _ := compiles(fixes, order); ok {
+		return fixes
+	}
+
+	sort.SliceStable(order, func(a, b int) bool { return outranks(fixes[order[a]].Agg, fixes[order[b]].Agg) })
+	absorbed := make(map[int]bool)
+	var kept []int
+	for _, i := range order {
+		ok, msg := compiles(fixes, append(append([]int{}, kept...), i))
+		if ok {
+			kept = append(kept, i)
+			continue
+		}
+		if j := partner(fixes, kept, i); merge && j >= 0 {
+			combined := FixResult{Agg: mergeIssues(fset, content, fixes[j].Agg, fixes[i].Agg)}
+			requestFix(fset, &combined)
+			if combined.Error == nil {
+				trial := append([]FixResult{}, fixes...)
+				trial[j] = combined
+				if ok, _ := compiles(trial, kept); ok {
+					log.Printf("%s 第 %d 行与第 %d 行的修复合在一起无法编译，已合并为一次修复", filename,
+						fset.Position(fixes[j].Agg.Pos).Line, fset.Position(fixes[i].Agg.Pos).Line)
+					fixes[j] = combined
+					absorbed[i] = true
+					continue
+				}
+			}
+		}
+		fixes[i].Patch, fixes[i].Edits = "", nil
+		fixes[i].Error = fmt.Errorf("与同一文件中级别更高的修复合在一起无法通过编译，已放弃此修复: %s", msg)
+	}
+
+	var result []FixResult
+	for i, res := range fixes {
+		if !absorbed[i] {
+			result = append(result, res)
+		}
+	}
+	return result
+}
+
+// partner 在已保留的补丁中找与 fixes[i] 位于同一函数、位置最近的一个，用于合并修复；找不到时返回 -1
+func partner(fixes []FixResult, kept []int, i int) int {
+	agg, best := fixes[i].Agg, -1
+	if agg.Function == "" {
+		return -1
+	}
+	distance := func(j int) token.Pos {
+		if d := fixes[j].Agg.Pos - agg.Pos; d >= 0 {
+			return d
+		}
+		return agg.Pos - fixes[j].Agg.Pos
+	}
+	for _, j := range kept {
+		if fixes[j].Agg.Function == agg.Function && (best < 0 || distance(j) < distance(best)) {
+			best = j
+		}
+	}
+	return best
+}
+
+// outranks 判断 a 是否比 b 更值得保留
+func outranks(a, b *AggregatedIssue) bool {
+	if a.Severity != b.Severity {
+		return a.Severity.AtLeast(b.Severity)
+	}
+	if a.Confidence != b.Confidence {
+		return a.Confidence > b.Confidence
+	}
+	return a.Pos < b.Pos
+}
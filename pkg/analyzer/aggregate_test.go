@@ -0,0 +1,119 @@
+package analyzer
+
+import (
+	"go/token"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hsdaoqi/golint-ai/checkers"
+)
+
+func TestAggregate(t *testing.T) {
+	src := "package demo\n\nfunc A() {\n\tf, err := os.Open(name)\n\tgo func() { wg.Done() }()\n\tx := 1\n}\n"
+	// 文件不在磁盘上（如 overlay），片段只能从传入的内容中读取
+	fset := token.NewFileSet()
+	tf := fset.AddFile(filepath.Join(t.TempDir(), "a.go"), -1, len(src))
+	tf.SetLinesForContent([]byte(src))
+
+	// issue 构造一条缺陷，覆盖 code 中从 from 首次出现到 to 最后一次出现的部分
+	issue := func(category, code, from, to string) checkers.Issue {
+		start := strings.Index(src, code) + strings.Index(code, from)
+		end := strings.Index(src, code) + strings.LastIndex(code, to) + len(to)
+		return checkers.Issue{
+			Pos: tf.Pos(start), End: tf.Pos(end), Category: category, Message: category,
+			Snippet: src[start:end], VarName: strings.ToLower(category[:1]), Severity: checkers.SeverityWarning, Confidence: 0.5,
+		}
+	}
+	open := "f, err := os.Open(name)"
+	goStmt := "go func() { wg.Done() }()"
+	tests := []struct {
+		name     string
+		issues   []checkers.Issue
+		want     [][]string // 每个聚合结果中的类别
+		snippets []string
+	}{
+		{
+			name:     "互不相交",
+			issues:   []checkers.Issue{issue("GoroutineLeak", goStmt, "go", "()"), issue("UnhandledError", open, "f", ")")},
+			want:     [][]string{{"UnhandledError"}, {"GoroutineLeak"}},
+			snippets: []string{open, goStmt},
+		},
+		{
+			name:     "嵌套",
+			issues:   []checkers.Issue{issue("NilPointer", open, "os", ")"), issue("UnhandledError", open, "f", ")")},
+			want:     [][]string{{"UnhandledError", "NilPointer"}},
+			snippets: []string{open},
+		},
+		{
+			name:     "起点相同",
+			issues:   []checkers.Issue{issue("ResourceLeak", open, "f", "err"), issue("UnhandledError", open, "f", ")")},
+			want:     [][]string{{"UnhandledError", "ResourceLeak"}},
+			snippets: []string{open},
+		},
+		{
+			name: "部分重叠时区间取并集并重新读取片段",
+			issues: []checkers.Issue{
+				issue("UnhandledError", open, "f", "Open"),
+				issue("NilPointer", "os.Open(name)\n\tgo func()", "Open", "go"),
+			},
+			want:     [][]string{{"UnhandledError", "NilPointer"}},
+			snippets: []string{open + "\n\tgo"},
+		},
+		{
+			name:     "首尾相接不算重叠",
+			issues:   []checkers.Issue{issue("UnhandledError", open, "f", "err"), issue("NilPointer", open, " :=", ")")},
+			want:     [][]string{{"UnhandledError"}, {"NilPointer"}},
+			snippets: []string{"f, err", " := os.Open(name)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var functions, fingerprints []string
+			for _, iss := range tt.issues {
+				functions = append(functions, "demo.A")
+				fingerprints = append(fingerprints, iss.Category+"-fp")
+			}
+			aggs := aggregate(fset, []byte(src), tt.issues, functions, fingerprints)
+			var got [][]string
+			for i, agg := range aggs {
+				got = append(got, agg.Categories)
+				if i < len(tt.snippets) && agg.Snippet != tt.snippets[i] {
+					t.Errorf("第 %d 个聚合的片段 = %q, want %q", i, agg.Snippet, tt.snippets[i])
+				}
+				if len(agg.Fingerprints) != len(agg.Categories) || len(agg.Messages) != len(agg.Categories) {
+					t.Errorf("第 %d 个聚合的指纹、提示与类别没有一一对应: %+v", i, agg)
+				}
+				if i > 0 && agg.Pos < aggs[i-1].End {
+					t.Errorf("聚合结果的区间相交: %d < %d", agg.Pos, aggs[i-1].End)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("aggregate 类别 = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// 合并后的级别、置信度取较高者，变量名去重
+func TestAggregateMergesAttributes(t *testing.T) {
+	fset := token.NewFileSet()
+	tf := fset.AddFile("a.go", -1, 100)
+	issues := []checkers.Issue{
+		{Pos: tf.Pos(10), End: tf.Pos(30), Category: "UnhandledError", VarName: "err", Severity: checkers.SeverityWarning, Confidence: 0.9},
+		{Pos: tf.Pos(12), End: tf.Pos(20), Category: "NilPointer", VarName: "f", Severity: checkers.SeverityCritical, Confidence: 0.6},
+		{Pos: tf.Pos(15), End: tf.Pos(25), Category: "ResourceLeak", VarName: "f", Severity: checkers.SeverityError, Confidence: 0.7},
+	}
+	aggs := aggregate(fset, nil, issues, []string{"demo.A", "demo.A", "demo.A"}, []string{"1", "2", "3"})
+	if len(aggs) != 1 {
+		t.Fatalf("aggregate 得到 %d 个结果, want 1", len(aggs))
+	}
+	agg := aggs[0]
+	if agg.Severity != checkers.SeverityCritical || agg.Confidence != 0.9 || agg.VarName != "err, f" {
+		t.Fatalf("合并结果 = 级别 %s，置信度 %v，变量 %q", agg.Severity, agg.Confidence, agg.VarName)
+	}
+	if agg.Pos != tf.Pos(10) || agg.End != tf.Pos(30) || agg.Function != "demo.A" {
+		t.Fatalf("合并结果的区间或函数不对: %+v", agg)
+	}
+}
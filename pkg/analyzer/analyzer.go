@@ -1,15 +1,21 @@
 package analyzer
 
 import (
-	"bufio"
 	"fmt"
 	"github.com/hsdaoqi/golint-ai/checkers"
-	"github.com/hsdaoqi/golint-ai/pkg/repairer"
+	"github.com/hsdaoqi/golint-ai/pkg/baseline"
+	"github.com/hsdaoqi/golint-ai/pkg/config"
+	"github.com/hsdaoqi/golint-ai/pkg/gitdiff"
+	"github.com/hsdaoqi/golint-ai/pkg/verifier"
+	"go/ast"
 	"go/token"
+	"go/types"
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/astutil"
 	"log"
 	"os"
+	"reflect"
 	"sort"
 	"strings"
 	"sync"
@@ -18,6 +24,36 @@ import (
 // FixMode 控制是仅扫描还是交互式修复
 var FixMode bool
 
+// MinSeverity 低于该级别的缺陷既不汇报也不送去修复，为空表示不过滤
+var MinSeverity checkers.Severity
+
+// NoAI 为 true 时只做检测，不请求 LLM（等同于配置 ai.enabled: false）
+var NoAI bool
+
+// Baseline 非空时，基线中已记录的缺陷既不汇报也不送去修复
+var Baseline *baseline.Baseline
+
+// ChangedLines 非空时只保留与变更行有交集的缺陷（diff 感知扫描），其余缺陷也不会送去修复
+var ChangedLines gitdiff.Changes
+
+// Enable / Disable 来自命令行的规则开关，与配置文件中的 checkers 段合并生效
+var (
+	Enable  []string
+	Disable []string
+)
+
+// VerifyTests 为 true 时，补丁还需通过所在包的测试：补丁前通过的测试在补丁后不能失败
+var VerifyTests bool
+
+// TestOptions 测试校验的参数
+var TestOptions verifier.TestOptions
+
+var (
+	activeOnce     sync.Once
+	activeCheckers []checkers.Checker
+	activeErr      error
+)
+
 // AggregatedIssue 聚合了同一个位置的所有缺陷
 type AggregatedIssue struct {
 	Pos        token.Pos
@@ -27,6 +63,14 @@ type AggregatedIssue struct {
 	Categories []string
 	Messages   []string
 	Filename   string
+	Severity   checkers.Severity // 聚合后取最严重的级别
+	Confidence float64           // 聚合后取最高的置信度
+
+	Function     string   // 所在函数，形如 pkg.(*T).Method，包级代码为空
+	Fingerprints []string // 每条原始缺陷的基线指纹
+	Findings     []string // 按函数修复时逐条列出的缺陷（含行号），非空表示补丁区间为整个函数体
+
+	existing []string // 补丁前文件中全部缺陷的指纹（含被过滤掉的），复查时据此区分原有缺陷与新引入的缺陷
 }
 
 // FixResult 存储 AI 的生成结果
@@ -34,70 +78,133 @@ type FixResult struct {
 	Agg   *AggregatedIssue
 	Patch string
 	Error error
+	Edits []analysis.TextEdit // 由 Patch 转换而来的标准文本编辑，展示、导出与落盘都基于它
+
+	Attempts int    // 请求 AI 的次数，补丁未通过编译或复查时会带着反馈重试
+	Outcome  string // 最后一份补丁的复查结论 (fixed/unchanged/regressed)，未经复查时为空
+	Commit   string // --git-commit 时提交该修复的短哈希
+}
+
+// Result 是一次 Pass 的汇总结果，供驱动层计算退出码
+type Result struct {
+	Findings []FixResult
 }
 
 var Analyzer = &analysis.Analyzer{
-	Name:     "errfix",
-	Doc:      "工业级 AI 自动化修复引擎 (支持多缺陷聚合与逆序修复)",
-	Requires: []*analysis.Analyzer{inspect.Analyzer},
-	Run:      run,
+	Name:       "errfix",
+	Doc:        "工业级 AI 自动化修复引擎 (支持多缺陷聚合与逆序修复)",
+	Requires:   []*analysis.Analyzer{inspect.Analyzer},
+	Run:        run,
+	ResultType: reflect.TypeOf((*Result)(nil)),
+}
+
+// loadCheckers 应用配置并选出本次启用的检查器，整个进程只执行一次
+func loadCheckers() ([]checkers.Checker, error) {
+	activeOnce.Do(func() {
+		cfg := config.Load().Checkers
+		for name, settings := range cfg.Settings {
+			if activeErr = checkers.Configure(name, settings); activeErr != nil {
+				return
+			}
+		}
+		activeCheckers, activeErr = checkers.Select(
+			append(append([]string{}, cfg.Enable...), Enable...),
+			append(append([]string{}, cfg.Disable...), Disable...),
+		)
+	})
+	return activeCheckers, activeErr
 }
 
 func run(pass *analysis.Pass) (interface{}, error) {
+	active, err := loadCheckers()
+	if err != nil {
+		return nil, err
+	}
+
+	ff, err := loadFilter()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{}
 	for _, f := range pass.Files {
-		// 1. 调用所有检查器收集原始 Issues
+		if ff.skip(pass.Fset.File(f.Pos()).Name(), f) {
+			continue
+		}
+
+		// 1. 调用所有已启用的检查器收集原始 Issues，并按级别与基线过滤
 		var rawIssues []checkers.Issue
-		rawIssues = append(rawIssues, checkers.ScanUnhandledError(pass, f)...)
-		rawIssues = append(rawIssues, checkers.ScanNilPointer(pass, f)...)
-		rawIssues = append(rawIssues, checkers.ScanResourceLeak(pass, f)...)
-		rawIssues = append(rawIssues, checkers.ScanHardcodedSecrets(pass, f)...)
-		rawIssues = append(rawIssues, checkers.ScanSQLInjection(pass, f)...)
-		rawIssues = append(rawIssues, checkers.ScanGoroutineLeak(pass, f)...)
+		var functions, fingerprints, existing []string
+		for _, iss := range checkers.ScanFile(pass, f, active) {
+			fn := enclosingFunc(pass, f, iss.Pos)
+			fp := baseline.Fingerprint(iss.Category, fn, iss.Snippet)
+			existing = append(existing, fp)
+			if !iss.Severity.AtLeast(MinSeverity) {
+				continue
+			}
+			if Baseline != nil && Baseline.Match(fp) {
+				continue
+			}
+			functions = append(functions, fn)
+			fingerprints = append(fingerprints, fp)
+			rawIssues = append(rawIssues, iss)
+		}
 		if len(rawIssues) == 0 {
 			continue
 		}
 
-		// 2. 【核心算法】：按位置(Pos)聚合缺陷
-		// 解决“同一行代码修复两次”的 Bug
-		issueMap := make(map[token.Pos]*AggregatedIssue)
-		for _, iss := range rawIssues {
-			posInfo := pass.Fset.Position(iss.Pos)
-			if existing, found := issueMap[iss.Pos]; found {
-				existing.Categories = append(existing.Categories, iss.Category)
-				existing.Messages = append(existing.Messages, iss.Message)
-			} else {
-				issueMap[iss.Pos] = &AggregatedIssue{
-					Pos:        iss.Pos,
-					End:        iss.End,
-					VarName:    iss.VarName,
-					Snippet:    iss.Snippet,
-					Categories: []string{iss.Category},
-					Messages:   []string{iss.Message},
-					Filename:   posInfo.Filename,
-				}
-			}
+		// 代码片段从驱动提供的内容中读取，与 pass.Fset 中的位置一致（overlay 中的文件同样如此）
+		filename := pass.Fset.File(f.Pos()).Name()
+		content, err := readFile(pass, filename)
+		if err != nil {
+			log.Printf("无法读取 %s，代码片段使用检查器给出的内容: %v", filename, err)
 		}
 
-		// 将 Map 转为 List 方便后续处理
+		// 2. 【核心算法】：把区间重叠或嵌套的缺陷聚合为一处
+		// 解决“同一段代码修复两次”以及补丁互相覆盖的 Bug
 		var aggregatedList []*AggregatedIssue
-		for _, agg := range issueMap {
+		for _, agg := range aggregate(pass.Fset, content, rawIssues, functions, fingerprints) {
+			if ChangedLines != nil && !ChangedLines.Overlaps(agg.Filename,
+				pass.Fset.Position(agg.Pos).Line, pass.Fset.Position(agg.End).Line) {
+				continue
+			}
 			aggregatedList = append(aggregatedList, agg)
 		}
 
-		// 3. 【并行层】：并发向 AI 申请修复方案
-		results := make([]FixResult, len(aggregatedList))
-		var wg sync.WaitGroup
-		for i, agg := range aggregatedList {
-			wg.Add(1)
-			go func(idx int, target *AggregatedIssue) {
-				defer wg.Done()
-				// 将多个缺陷类型拼接，告诉 AI 一次性修好
-				categoryDesc := strings.Join(target.Categories, " 且 ")
-				patch, err := repairer.GetFix(target.VarName, target.Snippet, "", categoryDesc)
-				results[idx] = FixResult{Agg: target, Patch: patch, Error: err}
-			}(i, agg)
+		var candidates []*AggregatedIssue
+		for _, agg := range aggregatedList {
+			// 修复模式下，不在修复范围内的缺陷直接记为跳过，不必请求 AI
+			if FixMode {
+				if reason := preFixSkipReason(agg); reason != "" {
+					Summary.record(pass.Fset, FixResult{Agg: agg}, "skipped", reason)
+					continue
+				}
+			}
+			candidates = append(candidates, agg)
+		}
+		if FuncScope {
+			candidates = groupByFunc(pass.Fset, f, content, candidates)
+		}
+		var results []FixResult
+		for _, agg := range candidates {
+			agg.existing = existing
+			results = append(results, FixResult{Agg: agg})
+		}
+
+		// 3. 【并行层】：并发向 AI 申请修复方案（检测模式下跳过）
+		if AIEnabled() {
+			var wg sync.WaitGroup
+			for i := range results {
+				wg.Add(1)
+				go func(res *FixResult) {
+					defer wg.Done()
+					requestFix(pass.Fset, res)
+				}(&results[i])
+			}
+			wg.Wait()
+			// 各补丁单独都能编译，合在一起仍可能冲突：合并重修或只保留级别更高的一份
+			results = combineFixes(pass.Fset, filename, content, results, true)
 		}
-		wg.Wait()
 
 		// 4. 【排序层】：按 Pos 倒序排列 (从文件末尾往开头修)
 		// 解决“修复后偏移量失效”的 Bug
@@ -105,78 +212,72 @@ func run(pass *analysis.Pass) (interface{}, error) {
 			return results[i].Agg.Pos > results[j].Agg.Pos
 		})
 
-		// 5. 【交互与输出层】：根据模式执行
+		// 5. 【输出层】：扫描模式下无论 AI 是否成功都汇报缺陷
+		// 修复模式只收集结果，待所有包分析完成后由 FixSession 统一交互，避免多个包的提示互相穿插
 		for _, res := range results {
 			if res.Error != nil {
 				log.Printf("AI 修复失败 [%s]: %v", res.Agg.VarName, res.Error)
-				continue
 			}
-
-			if FixMode {
-				// 修复模式：独占式交互
-				handleFixInteraction(pass, res)
-			} else {
-				// 扫描模式：仅打印和汇报
+			if !FixMode {
 				handleScanOutput(pass, res)
 			}
 		}
+		result.Findings = append(result.Findings, results...)
 	}
-	return nil, nil
+	return result, nil
 }
 
-// handleFixInteraction 处理 fix 命令的交互逻辑
-func handleFixInteraction(pass *analysis.Pass, res FixResult) {
-	fmt.Printf("\n" + strings.Repeat("=", 60))
-	fmt.Printf("\n缺陷位置: %s:%d", res.Agg.Filename, pass.Fset.Position(res.Agg.Pos).Line)
-	fmt.Printf("\n缺陷类别: %s", strings.Join(res.Agg.Categories, " & "))
-	fmt.Printf("\n修复建议: \n%s", res.Patch)
-	fmt.Printf("\n" + strings.Repeat("-", 60))
-	fmt.Print("\n是否应用此修复并写入文件? (y/n): ")
-
-	reader := bufio.NewReader(os.Stdin)
-	input, _ := reader.ReadString('\n')
-	if strings.ToLower(strings.TrimSpace(input)) == "y" {
-		applyFixToFile(pass, res.Agg, res.Patch)
-		fmt.Println("已保存。")
-	} else {
-		fmt.Println("已跳过。")
+// readFile 通过 pass.ReadFile 读取包中的源文件，与驱动加载的内容保持一致；驱动未提供 ReadFile 时直接读磁盘
+func readFile(pass *analysis.Pass, filename string) ([]byte, error) {
+	if pass.ReadFile != nil {
+		return pass.ReadFile(filename)
 	}
+	return os.ReadFile(filename)
 }
 
-// handleScanOutput 处理 scan 命令的输出逻辑
-func handleScanOutput(pass *analysis.Pass, res FixResult) {
-	// 在控制台打印带颜色的建议（方便预览）
-	fmt.Printf("\n[%s] 在 %s:%d 发现缺陷。AI 建议: \n%s\n",
-		strings.Join(res.Agg.Categories, "&"),
-		res.Agg.Filename,
-		pass.Fset.Position(res.Agg.Pos).Line,
-		res.Patch)
-
-	// 同时向框架汇报，这样可以使用 go vet 标准输出
-	pass.Report(analysis.Diagnostic{
-		Pos:     res.Agg.Pos,
-		Message: fmt.Sprintf("[%s] %s", strings.Join(res.Agg.Categories, "&"), strings.Join(res.Agg.Messages, "; ")),
-	})
+// enclosingFunc 返回 pos 所在的具名函数（函数字面量归属于外层函数），带包路径前缀
+func enclosingFunc(pass *analysis.Pass, f *ast.File, pos token.Pos) string {
+	path, _ := astutil.PathEnclosingInterval(f, pos, pos)
+	for _, node := range path {
+		fd, ok := node.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		name := fd.Name.Name
+		if fd.Recv != nil && len(fd.Recv.List) > 0 {
+			name = fmt.Sprintf("(%s).%s", types.ExprString(fd.Recv.List[0].Type), name)
+		}
+		return pass.Pkg.Path() + "." + name
+	}
+	return ""
 }
 
-// applyFixToFile 物理修改文件
-func applyFixToFile(pass *analysis.Pass, agg *AggregatedIssue, fixCode string) {
-	pos := pass.Fset.Position(agg.Pos)
-	end := pass.Fset.Position(agg.End)
+// AIEnabled 判断本次运行是否需要请求 LLM
+func AIEnabled() bool {
+	return !NoAI && config.Load().AI.Enabled
+}
 
-	content, err := os.ReadFile(pos.Filename)
-	if err != nil {
-		log.Printf("无法读取文件: %v", err)
-		return
+// handleScanOutput 处理 scan 命令的输出逻辑：向框架汇报，具体的展示格式由驱动层决定
+// 补丁连同导入变化以 SuggestedFix 的形式附在诊断上，cmd/golint-ai-fix 等 analysis 驱动可以用 -fix 直接应用（不经交互确认）；
+// 策略为 never 的类别只展示建议，不提供可自动应用的修复
+func handleScanOutput(pass *analysis.Pass, res FixResult) {
+	diag := analysis.Diagnostic{
+		Pos:      res.Agg.Pos,
+		End:      res.Agg.End,
+		Category: strings.Join(res.Agg.Categories, "&"),
+		Message: fmt.Sprintf("[%s][%s] %s (置信度 %.0f%%)", res.Agg.Severity,
+			strings.Join(res.Agg.Categories, "&"), strings.Join(res.Agg.Messages, "; "), res.Agg.Confidence*100),
 	}
-
-	// 构造新内容：前 + 补丁 + 后
-	newContent := append([]byte{}, content[:pos.Offset]...)
-	newContent = append(newContent, []byte(fixCode)...)
-	newContent = append(newContent, content[end.Offset:]...)
-
-	err = os.WriteFile(pos.Filename, newContent, 0644)
-	if err != nil {
-		log.Printf("写入失败: %v", err)
+	if len(res.Edits) > 0 && policyFor(res.Agg) != PolicyNever {
+		edits, err := res.SuggestedEdits(pass.Fset)
+		if err != nil {
+			log.Printf("无法计算补丁的导入变化 [%s]: %v", res.Agg.VarName, err)
+			edits = res.Edits
+		}
+		diag.SuggestedFixes = []analysis.SuggestedFix{{
+			Message:   fmt.Sprintf("AI 修复: %s", strings.Join(res.Agg.Categories, " & ")),
+			TextEdits: edits,
+		}}
 	}
+	pass.Report(diag)
 }
@@ -0,0 +1,267 @@
+package analyzer
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/imports"
+)
+
+// spanKind 补丁区间在原文件中对应的语法结构，决定 AI 补丁按什么解析
+type spanKind int
+
+const (
+	spanStmts spanKind = iota // 一条或连续多条语句
+	spanBlock                 // 完整的代码块，如按函数修复时的函数体
+	spanExpr                  // 单个表达式，如 SQL 查询调用
+)
+
+// importsMu imports.Process 共享进程级的包索引，串行调用更稳妥
+var importsMu sync.Mutex
+
+// patchEdits 把 AI 补丁按缺陷区间的语法结构解析并重新排版，转换为替换该区间的 TextEdit；
+// 补丁无法解析为对应的语句、代码块或表达式时返回错误，错误信息可直接反馈给 AI
+func patchEdits(fset *token.FileSet, agg *AggregatedIssue, content []byte, patch string) ([]analysis.TextEdit, error) {
+	if patch == "" {
+		return nil, nil
+	}
+	start, end := fset.Position(agg.Pos).Offset, fset.Position(agg.End).Offset
+	if start < 0 || end > len(content) || start > end {
+		return nil, fmt.Errorf("缺陷区间 [%d,%d) 越界", start, end)
+	}
+	text, err := reprint(spanKindOf(content, start, end), patch)
+	if err != nil {
+		return nil, err
+	}
+	return []analysis.TextEdit{{Pos: agg.Pos, End: agg.End, NewText: []byte(indent(text, content, start))}}, nil
+}
+
+// spanKindOf 解析原文件，判断 [start, end) 覆盖的语法结构
+func spanKindOf(content []byte, start, end int) spanKind {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", content, parser.SkipObjectResolution)
+	if err != nil {
+		return spanStmts
+	}
+	base := fset.File(f.Pos()).Base()
+	pos, stop := token.Pos(base+start), token.Pos(base+end)
+	path, exact := astutil.PathEnclosingInterval(f, pos, stop)
+	if len(path) == 0 || !exact || path[0].Pos() != pos || path[0].End() != stop {
+		return spanStmts // 多条语句落在同一代码块中
+	}
+	switch path[0].(type) {
+	case *ast.BlockStmt:
+		return spanBlock
+	case ast.Expr:
+		// 独立成句的调用（如 db.Query(q)）可以改写为多条语句
+		if len(path) > 1 {
+			if stmt, ok := path[1].(*ast.ExprStmt); ok && stmt.Pos() == pos && stmt.End() == stop {
+				return spanStmts
+			}
+		}
+		return spanExpr
+	}
+	return spanStmts
+}
+
+// reprint 把补丁解析为 kind 对应的语法结构，再经 go/format 输出；语句补丁去掉外层缩进（跨行字符串内部的行除外）
+func reprint(kind spanKind, patch string) (string, error) {
+	switch kind {
+	case spanExpr:
+		expr, err := parser.ParseExpr(patch)
+		if err != nil {
+			return "", fmt.Errorf("补丁无法解析为 Go 表达式: %v", err)
+		}
+		var buf bytes.Buffer
+		if err := format.Node(&buf, token.NewFileSet(), expr); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	case spanBlock:
+		out, err := formatFunc("func _() "+patch, "代码块")
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimPrefix(out, "func _() "), nil
+	default:
+		out, err := formatFunc("func _() {\n"+patch+"\n}", "语句")
+		if err != nil {
+			return "", err
+		}
+		body := strings.TrimPrefix(out, "func _() {")
+		body = strings.Trim(strings.TrimSuffix(body, "}"), "\n")
+		inLit := literalLines(body)
+		lines := strings.Split(body, "\n")
+		for i, line := range lines {
+			if !inLit[i] {
+				lines[i] = strings.TrimPrefix(line, "\t")
+			}
+		}
+		return strings.Join(lines, "\n"), nil
+	}
+}
+
+// formatFunc 把包在函数中的补丁解析并格式化，要求补丁恰好构成这一个函数，不能借机夹带其他声明
+func formatFunc(decl, what string) (string, error) {
+	const header = "package p\n\n"
+	src := header + decl + "\n"
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, parser.ParseComments|parser.SkipObjectResolution)
+	if err == nil && len(f.Decls) != 1 {
+		err = fmt.Errorf("补丁中包含多余的声明")
+	}
+	if err != nil {
+		return "", fmt.Errorf("补丁无法解析为 Go %s: %v", what, err)
+	}
+	out, err := format.Source([]byte(src))
+	if err != nil {
+		return "", fmt.Errorf("补丁无法解析为 Go %s: %v", what, err)
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(string(out), header), "\n"), nil
+}
+
+// indent 给补丁除首行外的每一行加上缺陷所在行的缩进，使替换后的代码与上下文对齐；
+// 跨行字符串字面量内部的行是字符串内容的一部分，保持原样
+func indent(text string, content []byte, start int) string {
+	lineStart := bytes.LastIndexByte(content[:start], '\n') + 1
+	line := content[lineStart:start]
+	prefix := string(line[:len(line)-len(bytes.TrimLeft(line, " \t"))])
+	if prefix == "" {
+		return text
+	}
+	inLit := literalLines(text)
+	lines := strings.Split(text, "\n")
+	for i := 1; i < len(lines); i++ {
+		if lines[i] != "" && !inLit[i] {
+			lines[i] = prefix + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// literalLines 按词法扫描 src，返回落在跨行字面量（原始字符串）内部的行（从 0 开始），
+// 即字面量起始行之后、直到结束行的各行，这些行的缩进属于字面量的值，不能改动
+func literalLines(src string) map[int]bool {
+	fset := token.NewFileSet()
+	file := fset.AddFile("", -1, len(src))
+	var s scanner.Scanner
+	s.Init(file, []byte(src), nil, 0)
+	lines := make(map[int]bool)
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			return lines
+		}
+		if tok != token.STRING || !strings.Contains(lit, "\n") {
+			continue
+		}
+		first := fset.Position(pos).Line
+		for l := first + 1; l <= first+strings.Count(lit, "\n"); l++ {
+			lines[l-1] = true
+		}
+	}
+}
+
+// FixFile 在内存中应用编辑，再像 goimports 一样补全补丁用到的导入、删除不再使用的导入，最后经 go/format 格式化
+func FixFile(fset *token.FileSet, filename string, content []byte, edits []analysis.TextEdit) ([]byte, error) {
+	spliced, err := ApplyEdits(fset, content, edits)
+	if err != nil {
+		return nil, err
+	}
+	return tidy(filename, spliced)
+}
+
+// tidy 整理导入并格式化整个文件，filename 用于查找同一模块中的包
+func tidy(filename string, src []byte) ([]byte, error) {
+	importsMu.Lock()
+	out, err := imports.Process(filename, src, &imports.Options{Comments: true, TabIndent: true, TabWidth: 8})
+	importsMu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("整理导入失败: %w", err)
+	}
+	if out, err = format.Source(out); err != nil {
+		return nil, fmt.Errorf("格式化失败: %w", err)
+	}
+	return out, nil
+}
+
+// SuggestedEdits 返回应用此修复所需的全部 TextEdit：替换缺陷区间的编辑，加上补丁引起的导入变化。
+// 供只接受 TextEdit 的使用方（singlechecker -fix、SARIF 等）使用，应用后得到的代码能够通过编译
+func (r FixResult) SuggestedEdits(fset *token.FileSet) ([]analysis.TextEdit, error) {
+	if len(r.Edits) == 0 {
+		return nil, nil
+	}
+	content, err := os.ReadFile(r.Agg.Filename)
+	if err != nil {
+		return nil, err
+	}
+	tidied, err := FixFile(fset, r.Agg.Filename, content, r.Edits)
+	if err != nil {
+		return nil, err
+	}
+	imports, err := importEdits(fset.File(r.Agg.Pos), content, tidied)
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]analysis.TextEdit{}, r.Edits...), imports...), nil
+}
+
+// importEdits 对比原文件与整理后的文件，把导入声明的变化表示为一处替换导入区间的 TextEdit
+func importEdits(tf *token.File, original, tidied []byte) ([]analysis.TextEdit, error) {
+	oldStart, oldEnd, err := importRegion(original)
+	if err != nil {
+		return nil, err
+	}
+	newStart, newEnd, err := importRegion(tidied)
+	if err != nil {
+		return nil, err
+	}
+	newText := tidied[newStart:newEnd]
+	if bytes.Equal(original[oldStart:oldEnd], newText) {
+		return nil, nil
+	}
+	return []analysis.TextEdit{{Pos: tf.Pos(oldStart), End: tf.Pos(oldEnd), NewText: newText}}, nil
+}
+
+// importRegion 返回包名之后到最后一个导入声明结束的区间，没有导入时为包名之后的空区间
+func importRegion(src []byte) (int, int, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.ImportsOnly|parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return 0, 0, err
+	}
+	start := fset.Position(f.Name.End()).Offset
+	end := start
+	for _, decl := range f.Decls {
+		if gd, ok := decl.(*ast.GenDecl); ok && gd.Tok == token.IMPORT {
+			end = fset.Position(gd.End()).Offset
+		}
+	}
+	return start, end, nil
+}
+
+// patchedLines 返回补丁在整理后的文件中所占的行。补丁位于导入声明之后，整理导入只会让它整体平移，
+// 因此按补丁之后未变的行数对齐；补丁本身被格式化改动时按总行数差平移，只是近似
+func patchedLines(spliced, tidied []byte, start, length int) (int, int) {
+	first := bytes.Count(spliced[:start], []byte("\n")) + 1
+	last := first + bytes.Count(spliced[start:start+length], []byte("\n"))
+	a, b := bytes.Split(spliced, []byte("\n")), bytes.Split(tidied, []byte("\n"))
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && bytes.Equal(a[prefix], b[prefix]) {
+		prefix++
+	}
+	if last <= prefix {
+		return first, last
+	}
+	shift := len(b) - len(a)
+	return first + shift, last + shift
+}
@@ -0,0 +1,139 @@
+package analyzer
+
+import (
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// SuggestedEdits 只经 TextEdit 应用（如 singlechecker -fix）时，也要带上补丁引起的导入变化
+func TestSuggestedEditsIncludeImports(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		old, new string // 被替换的语句与补丁
+		want     []string
+		dropped  []string
+	}{
+		{
+			name: "新增导入",
+			src:  "package demo\n\nfunc A() string {\n\tkey := \"sk-123\"\n\treturn key\n}\n",
+			old:  `key := "sk-123"`,
+			new:  `key := os.Getenv("KEY")`,
+			want: []string{`"os"`},
+		},
+		{
+			name:    "删除不再使用的导入",
+			src:     "package demo\n\nimport (\n\t\"fmt\"\n\t\"os\"\n)\n\nfunc A() {\n\tfmt.Println(os.Args)\n}\n",
+			old:     `fmt.Println(os.Args)`,
+			new:     `println(len(os.Args))`,
+			want:    []string{`"os"`},
+			dropped: []string{`"fmt"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "a.go")
+			if err := os.WriteFile(filename, []byte(tt.src), 0644); err != nil {
+				t.Fatal(err)
+			}
+			fset := token.NewFileSet()
+			f, err := parser.ParseFile(fset, filename, tt.src, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tf := fset.File(f.Pos())
+			start := strings.Index(tt.src, tt.old)
+			pos, end := tf.Pos(start), tf.Pos(start+len(tt.old))
+			res := FixResult{
+				Agg:   &AggregatedIssue{Pos: pos, End: end, Filename: filename},
+				Edits: []analysis.TextEdit{{Pos: pos, End: end, NewText: []byte(tt.new)}},
+			}
+
+			edits, err := res.SuggestedEdits(fset)
+			if err != nil {
+				t.Fatal(err)
+			}
+			applied, err := ApplyEdits(fset, []byte(tt.src), edits)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := format.Source(applied)
+			if err != nil {
+				t.Fatalf("应用后的文件无法解析: %v\n%s", err, applied)
+			}
+			want, err := FixFile(fset, filename, []byte(tt.src), res.Edits)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != string(want) {
+				t.Fatalf("只应用 TextEdit 的结果与 FixFile 不一致:\n%s\nwant:\n%s", got, want)
+			}
+			for _, s := range tt.want {
+				if !strings.Contains(string(got), s) {
+					t.Errorf("结果中缺少导入 %s:\n%s", s, got)
+				}
+			}
+			for _, s := range tt.dropped {
+				if strings.Contains(string(got), s) {
+					t.Errorf("结果中仍有不再使用的导入 %s:\n%s", s, got)
+				}
+			}
+		})
+	}
+}
+
+// 补丁中的跨行原始字符串是字面量的值：去掉外层缩进、对齐上下文时都不能改动其中的行
+func TestPatchEditsKeepsRawStrings(t *testing.T) {
+	const query = "`SELECT *\n\tFROM t\nWHERE id = ?`"
+	tests := []struct {
+		name       string
+		src, old   string
+		patch      string
+		wantPrefix string // 替换后紧跟在 query 之后的代码
+	}{
+		{
+			name:       "语句",
+			src:        "package demo\n\nfunc A() {\n\tif true {\n\t\tq := \"x\"\n\t\t_ = q\n\t}\n}\n",
+			old:        "q := \"x\"",
+			patch:      "\tq := " + query + "\n\t_ = q",
+			wantPrefix: "\n\t\t_ = q",
+		},
+		{
+			name:       "函数体",
+			src:        "package demo\n\nfunc A() {\n\tq := \"x\"\n\t_ = q\n}\n",
+			old:        "{\n\tq := \"x\"\n\t_ = q\n}",
+			patch:      "{\nq := " + query + "\n_ = q\n}",
+			wantPrefix: "\n\t_ = q\n}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fset := token.NewFileSet()
+			f, err := parser.ParseFile(fset, "a.go", tt.src, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tf := fset.File(f.Pos())
+			start := strings.Index(tt.src, tt.old)
+			agg := &AggregatedIssue{Pos: tf.Pos(start), End: tf.Pos(start + len(tt.old))}
+			edits, err := patchEdits(fset, agg, []byte(tt.src), tt.patch)
+			if err != nil {
+				t.Fatal(err)
+			}
+			text := string(edits[0].NewText)
+			if !strings.Contains(text, query+tt.wantPrefix) {
+				t.Fatalf("原始字符串的内容被改动:\n%s", text)
+			}
+			if _, err := parser.ParseFile(token.NewFileSet(), "", tt.src[:start]+text+tt.src[start+len(tt.old):], 0); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
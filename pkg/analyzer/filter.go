@@ -0,0 +1,82 @@
+package analyzer
+
+import (
+	"go/ast"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/hsdaoqi/golint-ai/pkg/config"
+	"github.com/hsdaoqi/golint-ai/pkg/ignore"
+)
+
+var (
+	filterOnce sync.Once
+	fileFilter *filter
+	filterErr  error
+)
+
+// filter 根据 analysis 配置与 .golintaiignore 决定哪些文件不参与分析
+type filter struct {
+	root        string // 相对路径的基准目录，即忽略文件所在目录
+	skipDirs    []string
+	ignoreTests bool
+	matcher     *ignore.Matcher
+}
+
+// loadFilter 读取配置与忽略文件，整个进程只执行一次
+func loadFilter() (*filter, error) {
+	filterOnce.Do(func() {
+		cfg := config.Load().Analysis
+		ff := &filter{skipDirs: cfg.SkipDirs, ignoreTests: cfg.IgnoreTests, matcher: &ignore.Matcher{}}
+		if ff.root, filterErr = os.Getwd(); filterErr != nil {
+			return
+		}
+		if cfg.IgnoreFile != "" {
+			path := cfg.IgnoreFile
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(ff.root, path)
+			}
+			ff.root = filepath.Dir(path)
+			if ff.matcher, filterErr = ignore.Load(path); filterErr != nil {
+				return
+			}
+		}
+		fileFilter = ff
+	})
+	return fileFilter, filterErr
+}
+
+// skip 判断文件是否应跳过：生成代码、测试文件、skip_dirs 以及忽略文件中的规则
+func (ff *filter) skip(filename string, f *ast.File) bool {
+	if ast.IsGenerated(f) {
+		return true
+	}
+	if ff.ignoreTests && strings.HasSuffix(filename, "_test.go") {
+		return true
+	}
+
+	rel, err := filepath.Rel(ff.root, filename)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		rel = filename // 不在基准目录下时只按目录名匹配 skip_dirs
+	}
+	rel = filepath.ToSlash(rel)
+
+	dirs := strings.Split(filepath.ToSlash(filepath.Dir(rel)), "/")
+	for _, skip := range ff.skipDirs {
+		skip = strings.Trim(filepath.ToSlash(skip), "/")
+		if strings.Contains(skip, "/") {
+			if strings.HasPrefix(rel, skip+"/") {
+				return true
+			}
+			continue
+		}
+		for _, d := range dirs {
+			if d == skip {
+				return true
+			}
+		}
+	}
+	return ff.matcher.Match(rel)
+}
@@ -0,0 +1,55 @@
+package analyzer
+
+import (
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"testing"
+
+	"github.com/hsdaoqi/golint-ai/pkg/ignore"
+)
+
+func TestFilterSkip(t *testing.T) {
+	root := filepath.FromSlash("/repo")
+	ff := &filter{
+		root:        root,
+		skipDirs:    []string{"third_party", "internal/legacy/"},
+		ignoreTests: true,
+		matcher:     ignore.New([]string{"*.pb.go", "!keep.pb.go"}),
+	}
+	const plain = "package demo\n"
+	const generated = "// Code generated by protoc-gen-go. DO NOT EDIT.\n\npackage demo\n"
+	tests := []struct {
+		name string
+		file string // 相对 root 的斜杠路径，以 / 开头时为绝对路径
+		src  string
+		want bool
+	}{
+		{"普通文件", "pkg/a.go", plain, false},
+		{"生成代码", "pkg/a.go", generated, true},
+		{"测试文件", "pkg/a_test.go", plain, true},
+		{"skip_dirs 按目录名匹配任意层级", "pkg/third_party/a.go", plain, true},
+		{"skip_dirs 目录名需完整匹配", "pkg/third_party_x/a.go", plain, false},
+		{"skip_dirs 中的路径相对 root", "internal/legacy/a.go", plain, true},
+		{"skip_dirs 中的路径不匹配其他层级", "pkg/internal/legacy/a.go", plain, false},
+		{"忽略文件中的规则", "pkg/a.pb.go", plain, true},
+		{"忽略文件中的否定规则", "pkg/keep.pb.go", plain, false},
+		{"root 之外只按目录名匹配", "/other/third_party/a.go", plain, true},
+		{"root 之外的普通文件", "/other/a.go", plain, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(root, filepath.FromSlash(tt.file))
+			if filepath.IsAbs(filepath.FromSlash(tt.file)) {
+				filename = filepath.FromSlash(tt.file)
+			}
+			f, err := parser.ParseFile(token.NewFileSet(), filename, tt.src, parser.ParseComments)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := ff.skip(filename, f); got != tt.want {
+				t.Fatalf("skip(%s) = %v, want %v", filename, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package analyzer
+
+import (
+	"fmt"
+	"go/token"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hsdaoqi/golint-ai/pkg/config"
+	"github.com/hsdaoqi/golint-ai/pkg/repairer"
+	"github.com/hsdaoqi/golint-ai/pkg/verifier"
+	"golang.org/x/tools/go/analysis"
+)
+
+// requestFix 向 AI 申请修复方案并填入 res：先在文件副本上校验补丁能否编译，再复查缺陷是否真正消除，
+// 开启 VerifyTests 时还要求补丁前通过的测试在补丁后仍然通过。
+// 不通过时带着编译错误或复查结论重新申请，最多重试 ai.max_retries 次，只保留通过校验的补丁
+func requestFix(fset *token.FileSet, res *FixResult) {
+	agg := res.Agg
+	content, err := os.ReadFile(agg.Filename)
+	if err != nil {
+		res.Error = fmt.Errorf("无法读取文件: %w", err)
+		return
+	}
+
+	// 将多个缺陷类型拼接，告诉 AI 一次性修好
+	categoryDesc := strings.Join(agg.Categories, " 且 ")
+	contextErr := ""
+	for res.Attempts = 1; res.Attempts <= 1+config.Load().AI.MaxRetries; res.Attempts++ {
+		var patch string
+		if len(agg.Findings) > 0 {
+			patch, err = repairer.GetFuncFix(agg.Snippet, agg.Findings, contextErr, categoryDesc)
+		} else {
+			patch, err = repairer.GetFix(agg.VarName, agg.Snippet, contextErr, categoryDesc)
+		}
+		if err != nil {
+			res.Error = err
+			return
+		}
+		edits, outcome, reason, err := checkPatch(fset, agg, content, patch)
+		if err != nil {
+			res.Outcome, res.Error = "", err
+			return
+		}
+		res.Outcome, contextErr = outcome, reason
+		if res.Outcome == OutcomeFixed {
+			res.Patch, res.Edits = patch, edits
+			return
+		}
+	}
+	res.Attempts--
+	if res.Outcome != "" {
+		res.Error = fmt.Errorf("补丁经过 %d 次尝试仍未通过复查 (%s): %s", res.Attempts, res.Outcome, contextErr)
+		return
+	}
+	res.Error = fmt.Errorf("补丁经过 %d 次尝试仍无法通过编译: %s", res.Attempts, contextErr)
+}
+
+func formatFailures(failures []verifier.TestFailure) string {
+	lines := make([]string, len(failures))
+	for i, f := range failures {
+		lines[i] = f.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// checkPatch 校验一份补丁：按语法结构替换缺陷区间、整理导入并格式化，在所在包中编译并复查，开启 VerifyTests 时还要运行测试。
+// 补丁被拒绝时 reason 说明原因，可以反馈给 AI；outcome 为复查结论，补丁无法解析或编译时为空。err 表示校验本身无法进行
+func checkPatch(fset *token.FileSet, agg *AggregatedIssue, content []byte, patch string) (edits []analysis.TextEdit, outcome, reason string, err error) {
+	edits, err = patchEdits(fset, agg, content, patch)
+	if err != nil {
+		return nil, "", err.Error(), nil
+	}
+	spliced, err := ApplyEdits(fset, content, edits)
+	if err != nil {
+		return nil, "", "", err
+	}
+	newContent, err := tidy(agg.Filename, spliced)
+	if err != nil {
+		return nil, "", err.Error(), nil
+	}
+	vr, err := verifier.ValidatePatch(agg.Filename, newContent)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("无法校验补丁: %w", err)
+	}
+	if !vr.OK() {
+		return nil, "", verifier.FormatErrors(vr.Errors), nil
+	}
+	length := 0
+	if len(edits) > 0 {
+		length = len(edits[0].NewText)
+	}
+	first, last := patchedLines(spliced, newContent, fset.Position(agg.Pos).Offset, length)
+	outcome, reason, err = recheck(vr, agg, newContent, first, last)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("无法复查补丁: %w", err)
+	}
+	if outcome == OutcomeFixed && VerifyTests {
+		failures, err := verifier.VerifyTests(agg.Filename, newContent, TestOptions)
+		if err != nil {
+			return nil, "", "", fmt.Errorf("无法运行测试: %w", err)
+		}
+		if len(failures) > 0 {
+			return nil, OutcomeRegressed, "补丁导致以下原本通过的测试失败:\n" + formatFailures(failures), nil
+		}
+	}
+	return edits, outcome, reason, nil
+}
+
+// ApplyEdits 在内存中把同一文件的编辑原样拼接到 content 上，编辑区间重叠时返回错误；写入文件前应使用 FixFile
+func ApplyEdits(fset *token.FileSet, content []byte, edits []analysis.TextEdit) ([]byte, error) {
+	sorted := append([]analysis.TextEdit(nil), edits...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Pos < sorted[j].Pos })
+
+	var out []byte
+	last := 0
+	for _, e := range sorted {
+		start, end := fset.Position(e.Pos).Offset, fset.Position(e.End).Offset
+		if start < last || end < start || end > len(content) {
+			return nil, fmt.Errorf("编辑区间 [%d,%d) 与前一处编辑重叠或越界", start, end)
+		}
+		out = append(out, content[last:start]...)
+		out = append(out, e.NewText...)
+		last = end
+	}
+	return append(out, content[last:]...), nil
+}
@@ -0,0 +1,107 @@
+package analyzer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"sort"
+	"strings"
+
+	"github.com/hsdaoqi/golint-ai/checkers"
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// FuncScope 为 true 时按函数批量修复：同一函数（含其中的函数字面量）内的缺陷合并为一次 AI 请求，整个函数体作为一个补丁替换
+var FuncScope bool
+
+// funcGroup 同一函数中的缺陷
+type funcGroup struct {
+	node ast.Node // *ast.FuncDecl 或 *ast.FuncLit
+	body *ast.BlockStmt
+	aggs []*AggregatedIssue
+}
+
+// groupByFunc 把 aggs 按所在函数分组，每组合并为一个以函数体为区间的缺陷；嵌套的函数字面量并入外层函数，
+// 不在任何函数中的缺陷（如包级变量）保持原样
+func groupByFunc(fset *token.FileSet, f *ast.File, content []byte, aggs []*AggregatedIssue) []*AggregatedIssue {
+	var result []*AggregatedIssue
+	var groups []*funcGroup
+	byNode := make(map[ast.Node]*funcGroup)
+	for _, agg := range aggs {
+		node, body := enclosingBody(f, agg.Pos, agg.End)
+		if node == nil {
+			result = append(result, agg)
+			continue
+		}
+		g, ok := byNode[node]
+		if !ok {
+			g = &funcGroup{node: node, body: body}
+			byNode[node] = g
+			groups = append(groups, g)
+		}
+		g.aggs = append(g.aggs, agg)
+	}
+
+	// 按起点排序后，起点落在前一组函数体内的组就是嵌套在其中的函数字面量
+	sort.Slice(groups, func(i, j int) bool { return groups[i].body.Pos() < groups[j].body.Pos() })
+	var merged []*funcGroup
+	for _, g := range groups {
+		if n := len(merged); n > 0 && g.body.Pos() < merged[n-1].body.End() {
+			merged[n-1].aggs = append(merged[n-1].aggs, g.aggs...)
+			continue
+		}
+		merged = append(merged, g)
+	}
+	for _, g := range merged {
+		result = append(result, g.issue(fset, content))
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Pos < result[j].Pos })
+	return result
+}
+
+// enclosingBody 返回包含 [pos, end) 的最内层函数及其函数体
+func enclosingBody(f *ast.File, pos, end token.Pos) (ast.Node, *ast.BlockStmt) {
+	path, _ := astutil.PathEnclosingInterval(f, pos, end)
+	for _, node := range path {
+		switch fn := node.(type) {
+		case *ast.FuncDecl:
+			if fn.Body != nil {
+				return fn, fn.Body
+			}
+		case *ast.FuncLit:
+			return fn, fn.Body
+		}
+	}
+	return nil, nil
+}
+
+// issue 把组内的缺陷合并为一个以函数体为补丁区间的缺陷，Snippet 为完整的函数源码，供 AI 统筹修复
+func (g *funcGroup) issue(fset *token.FileSet, content []byte) *AggregatedIssue {
+	sort.Slice(g.aggs, func(i, j int) bool { return g.aggs[i].Pos < g.aggs[j].Pos })
+	first := g.aggs[0]
+	fa := &AggregatedIssue{
+		Pos:        g.body.Pos(),
+		End:        g.body.End(),
+		Filename:   first.Filename,
+		Severity:   first.Severity,
+		Confidence: first.Confidence,
+		Function:   first.Function,
+	}
+	fa.Snippet = snippet(fset, content, g.node.Pos(), g.node.End(), "")
+	for _, agg := range g.aggs {
+		fa.Categories = append(fa.Categories, agg.Categories...)
+		fa.Messages = append(fa.Messages, agg.Messages...)
+		fa.Fingerprints = append(fa.Fingerprints, agg.Fingerprints...)
+		fa.Severity = checkers.MaxSeverity(fa.Severity, agg.Severity)
+		fa.Confidence = max(fa.Confidence, agg.Confidence)
+		for _, name := range strings.Split(agg.VarName, ", ") {
+			fa.addVarName(name)
+		}
+		code := strings.TrimSpace(strings.SplitN(agg.Snippet, "\n", 2)[0])
+		for i, category := range agg.Categories {
+			fa.Findings = append(fa.Findings, fmt.Sprintf("第 %d 行 `%s`: [%s] %s",
+				fset.Position(agg.Pos).Line, code, category, agg.Messages[i]))
+		}
+	}
+	return fa
+}
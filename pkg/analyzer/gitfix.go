@@ -0,0 +1,124 @@
+package analyzer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hsdaoqi/golint-ai/pkg/gitcommit"
+	"github.com/hsdaoqi/golint-ai/pkg/gitdiff"
+	"github.com/hsdaoqi/golint-ai/pkg/journal"
+	"golang.org/x/tools/go/analysis"
+)
+
+// GitCommit 为 true 时每个修复单独生成一个本地提交，便于逐个 cherry-pick 或 revert
+var GitCommit bool
+
+// GitBranch 非空时先从当前 HEAD 创建该分支，修复提交在新分支上（隐含 GitCommit）
+var GitBranch string
+
+func gitMode() bool {
+	return GitCommit || GitBranch != ""
+}
+
+// checkClean 确认文件没有未提交的修改，否则提交修复时会把用户的改动一并带入
+func checkClean(name string) error {
+	dirty, err := gitcommit.Dirty(name)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("文件有未提交的修改，无法单独提交修复")
+	}
+	return nil
+}
+
+// commitEach 逐个提交已写入的修复：文件依次恢复为只含前 k 个修复的内容并提交，最后一次提交即为最终内容。
+// 失败时直接返回错误，由调用方撤销本次的提交与文件改动
+func (s *FixSession) commitEach(staged []fileFixes) error {
+	for _, ff := range staged {
+		info, err := os.Stat(ff.name)
+		if err != nil {
+			return err
+		}
+		for k, it := range ff.items {
+			edits := append([]analysis.TextEdit{}, it.res.Edits...)
+			for _, prev := range ff.items[:k] {
+				edits = append(edits, prev.res.Edits...)
+			}
+			content, err := FixFile(s.fset, ff.name, ff.original, edits)
+			if err == nil {
+				err = journal.WriteFile(ff.name, content, info.Mode().Perm())
+			}
+			if err == nil {
+				it.res.Commit, err = gitcommit.Commit(ff.name, s.commitMessage(it.res))
+			}
+			if err != nil {
+				return fmt.Errorf("%s 的第 %d 个修复提交失败: %w", ff.name, k+1, err)
+			}
+		}
+	}
+	return nil
+}
+
+// rollbackCommits 提交中途失败时整体撤销：分支移回写入前的 head 并取消暂存修复的文件，
+// 再由修复日志把所有文件恢复原状，返回说明 cause 的错误
+func rollbackCommits(tx *journal.Session, head string, staged []fileFixes, cause error) error {
+	var paths []string
+	for _, ff := range staged {
+		paths = append(paths, ff.name)
+		for _, it := range ff.items {
+			it.res.Commit = ""
+		}
+	}
+	errs := []error{}
+	if err := gitcommit.Rewind(head, paths); err != nil {
+		errs = append(errs, err)
+	}
+	if err := tx.Rollback(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("%v；撤销本次提交与文件改动同样失败: %w", cause, err)
+	}
+	return fmt.Errorf("%w；已撤销本次的提交并恢复所有文件", cause)
+}
+
+// commitMessage 根据缺陷的类别、位置与提示生成提交说明
+func (s *FixSession) commitMessage(res FixResult) string {
+	agg := res.Agg
+	location := fmt.Sprintf("%s:%d", gitPath(agg.Filename), s.fset.Position(agg.Pos).Line)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "golint-ai: 修复 %s 的 %s\n\n", location, strings.Join(agg.Categories, " & "))
+	for i, category := range agg.Categories {
+		fmt.Fprintf(&sb, "- [%s] %s\n", category, agg.Messages[i])
+	}
+	if agg.Function != "" {
+		fmt.Fprintf(&sb, "\n所在函数: %s\n", agg.Function)
+	}
+	fmt.Fprintf(&sb, "级别: %s，置信度 %.0f%%\n", agg.Severity, agg.Confidence*100)
+	if res.Attempts > 0 {
+		fmt.Fprintf(&sb, "\n由 golint-ai 生成的 AI 修复，第 %d 次尝试通过编译与复查。\n", res.Attempts)
+	} else {
+		fmt.Fprint(&sb, "\n由 golint-ai 生成的 AI 修复，补丁经过人工编辑。\n")
+	}
+	return sb.String()
+}
+
+// gitPath 返回相对仓库根目录的路径
+func gitPath(filename string) string {
+	root, err := gitdiff.Root()
+	if err != nil {
+		return filename
+	}
+	if resolved, err := filepath.EvalSymlinks(filename); err == nil {
+		filename = resolved
+	}
+	if rel, err := filepath.Rel(root, filename); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return filename
+}
@@ -0,0 +1,236 @@
+package analyzer
+
+import (
+	"fmt"
+	"go/token"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hsdaoqi/golint-ai/pkg/config"
+	"golang.org/x/tools/go/analysis"
+)
+
+// 修复策略，在 config.yaml 的 fix.policy 中按类别配置
+const (
+	PolicyAuto  = "auto"  // 无需确认直接应用
+	PolicyAsk   = "ask"   // 交互确认，--yes 时视为同意（未配置的类别默认如此）
+	PolicyNever = "never" // 从不应用，也不会为其请求 AI
+)
+
+// 非交互批量修复的命令行控制
+var (
+	AssumeYes      bool     // --yes：对需要确认的修复一律同意
+	DryRun         bool     // --dry-run：只演练，不写文件
+	OnlyCategories []string // --only-categories：只修复这些类别
+	MaxFixes       int      // --max-fixes：最多应用多少处修复，0 表示不限
+)
+
+// FixOutcome 一条修复的最终去向
+type FixOutcome struct {
+	Filename   string
+	Line       int
+	Categories []string
+	Reason     string // 跳过或失败的原因
+	Attempts   int    // 请求 AI 的次数
+	Outcome    string // 补丁复查结论
+	Commit     string // 提交该修复的 git 提交
+}
+
+// FixSummary fix 命令结束时打印的汇总
+type FixSummary struct {
+	mu      sync.Mutex
+	Applied []FixOutcome
+	Skipped []FixOutcome
+	Failed  []FixOutcome
+	Session string // 修复日志中的会话编号，可用 golint-ai undo 撤销
+}
+
+// Summary 本次 fix 运行的汇总，分析阶段会跨包并发记录
+var Summary = &FixSummary{}
+
+// BatchMode 判断是否以非交互方式运行 fix
+func BatchMode() bool {
+	return AssumeYes || DryRun
+}
+
+// ValidatePolicy 检查配置中的修复策略是否合法
+func ValidatePolicy() error {
+	for category, policy := range config.Load().Fix.Policy {
+		switch strings.ToLower(policy) {
+		case PolicyAuto, PolicyAsk, PolicyNever:
+		default:
+			return fmt.Errorf("fix.policy.%s: 未知的策略 %q (可选: auto/ask/never)", category, policy)
+		}
+	}
+	return nil
+}
+
+// policyFor 返回聚合缺陷的修复策略，多个类别取最严格的一个
+func policyFor(agg *AggregatedIssue) string {
+	rank := map[string]int{PolicyAuto: 0, PolicyAsk: 1, PolicyNever: 2}
+	policies := config.Load().Fix.Policy
+	result := PolicyAuto
+	for _, category := range agg.Categories {
+		p := PolicyAsk
+		for key, value := range policies {
+			if strings.EqualFold(key, category) {
+				p = strings.ToLower(value)
+			}
+		}
+		if rank[p] > rank[result] {
+			result = p
+		}
+	}
+	return result
+}
+
+// preFixSkipReason 在请求 AI 之前就能确定要跳过的缺陷，返回跳过原因
+func preFixSkipReason(agg *AggregatedIssue) string {
+	if len(OnlyCategories) > 0 {
+		matched := false
+		for _, category := range agg.Categories {
+			matched = matched || containsFold(OnlyCategories, category)
+		}
+		if !matched {
+			return "不在 --only-categories 中"
+		}
+	}
+	if policyFor(agg) == PolicyNever {
+		return "策略禁止修复此类缺陷"
+	}
+	return ""
+}
+
+// PreviewFixes 为 fix --diff / --patch-out 挑选要写入预览的修复：与批量修复一样按文件、位置的顺序
+// 计入 --max-fixes，失败与超出上限的修复记入 Summary；选中的修复由 PreviewFile 应用后才记为已应用。
+// --only-categories 与策略为 never 的类别在分析阶段就已跳过；需要确认的修复照常预览，是否应用由使用者决定
+func PreviewFixes(fset *token.FileSet, findings []FixResult) []FixResult {
+	sorted := append([]FixResult{}, findings...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i].Agg, sorted[j].Agg
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		return a.Pos < b.Pos
+	})
+	var selected []FixResult
+	for _, res := range sorted {
+		switch {
+		case res.Error != nil:
+			Summary.record(fset, res, "failed", res.Error.Error())
+		case len(res.Edits) == 0:
+			Summary.record(fset, res, "failed", "AI 未给出补丁")
+		case MaxFixes > 0 && len(selected) >= MaxFixes:
+			Summary.record(fset, res, "skipped", "已达到 --max-fixes 上限")
+		default:
+			selected = append(selected, res)
+		}
+	}
+	return selected
+}
+
+// PreviewFile 在内存中应用 PreviewFixes 选出的同一文件中的修复（按位置排序），返回原内容与修复后的内容，
+// 并把每条修复的去向记入 Summary：与前面的修复重叠的记为失败；FixFile 失败时该文件的修复全部记为失败，修复后的内容为 nil。
+// 只有读取文件失败时返回错误
+func PreviewFile(fset *token.FileSet, filename string, fixes []FixResult) ([]byte, []byte, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		for _, res := range fixes {
+			Summary.record(fset, res, "failed", "无法读取文件")
+		}
+		return nil, nil, fmt.Errorf("无法读取文件: %w", err)
+	}
+
+	var inFile []FixResult
+	var edits []analysis.TextEdit
+	last := token.NoPos
+	for _, res := range fixes {
+		if res.Agg.Pos < last {
+			Summary.record(fset, res, "failed", "与同一文件中的其他修复重叠")
+			continue
+		}
+		last = res.Agg.End
+		inFile = append(inFile, res)
+		edits = append(edits, res.Edits...)
+	}
+	newContent, err := FixFile(fset, filename, content, edits)
+	for _, res := range inFile {
+		if err != nil {
+			Summary.record(fset, res, "failed", err.Error())
+		} else {
+			Summary.record(fset, res, "applied", "")
+		}
+	}
+	if err != nil {
+		return content, nil, nil
+	}
+	return content, newContent, nil
+}
+
+// record 记录一条修复的去向
+func (s *FixSummary) record(fset *token.FileSet, res FixResult, status, reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o := FixOutcome{
+		Filename:   res.Agg.Filename,
+		Line:       fset.Position(res.Agg.Pos).Line,
+		Categories: res.Agg.Categories,
+		Reason:     reason,
+		Attempts:   res.Attempts,
+		Outcome:    res.Outcome,
+		Commit:     res.Commit,
+	}
+	switch status {
+	case "applied":
+		s.Applied = append(s.Applied, o)
+	case "skipped":
+		s.Skipped = append(s.Skipped, o)
+	default:
+		s.Failed = append(s.Failed, o)
+	}
+}
+
+func (s *FixSummary) setSession(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Session = id
+}
+
+// Print 打印汇总，dry-run 时"已应用"表示"将应用"
+func (s *FixSummary) Print(w io.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	applied := "已应用"
+	if DryRun {
+		applied = "将应用 (dry-run)"
+	}
+	fmt.Fprintf(w, "\n修复汇总: %s %d，已跳过 %d，失败 %d\n", applied, len(s.Applied), len(s.Skipped), len(s.Failed))
+	printList := func(label string, list []FixOutcome) {
+		for _, o := range list {
+			line := fmt.Sprintf("  [%s] %s:%d %s", label, o.Filename, o.Line, strings.Join(o.Categories, " & "))
+			if o.Attempts > 0 {
+				line += fmt.Sprintf(" (AI 尝试 %d 次", o.Attempts)
+				if o.Outcome != "" {
+					line += ", 复查: " + o.Outcome
+				}
+				line += ")"
+			}
+			if o.Commit != "" {
+				line += " 提交 " + o.Commit
+			}
+			if o.Reason != "" {
+				line += " — " + o.Reason
+			}
+			fmt.Fprintln(w, line)
+		}
+	}
+	printList(applied, s.Applied)
+	printList("已跳过", s.Skipped)
+	printList("失败", s.Failed)
+	if s.Session != "" {
+		fmt.Fprintf(w, "修改已记录为会话 %s，可用 golint-ai undo %s 撤销\n", s.Session, s.Session)
+	}
+}
@@ -0,0 +1,84 @@
+package analyzer
+
+import (
+	"errors"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// 预览按文件、位置的顺序计入 --max-fixes，失败与超出上限的修复不进入补丁；选中的修复此时还不算已应用
+func TestPreviewFixes(t *testing.T) {
+	fset := token.NewFileSet()
+	tf := fset.AddFile("a.go", -1, 100)
+	fix := func(filename string, offset int, err error) FixResult {
+		pos := tf.Pos(offset)
+		res := FixResult{Agg: &AggregatedIssue{Filename: filename, Pos: pos, End: pos + 1}, Error: err}
+		if err == nil {
+			res.Edits = []analysis.TextEdit{{Pos: pos, End: pos + 1}}
+		}
+		return res
+	}
+	findings := []FixResult{
+		fix("b.go", 10, nil),
+		fix("a.go", 50, nil),
+		fix("a.go", 20, errors.New("编译失败")),
+		fix("a.go", 30, nil),
+	}
+
+	defer func(n int, s *FixSummary) { MaxFixes, Summary = n, s }(MaxFixes, Summary)
+	MaxFixes, Summary = 2, &FixSummary{}
+	got := PreviewFixes(fset, findings)
+	if len(got) != 2 || got[0].Agg != findings[3].Agg || got[1].Agg != findings[1].Agg {
+		t.Fatalf("PreviewFixes 应按位置选出 a.go 中的两处修复，得到 %d 处", len(got))
+	}
+	if len(Summary.Applied) != 0 || len(Summary.Skipped) != 1 || len(Summary.Failed) != 1 {
+		t.Fatalf("汇总 = 应用 %d，跳过 %d，失败 %d", len(Summary.Applied), len(Summary.Skipped), len(Summary.Failed))
+	}
+}
+
+// 选中的修复应用到文件之后才记为已应用：重叠的修复记为失败，补丁无法应用时整个文件的修复都记为失败
+func TestPreviewFile(t *testing.T) {
+	src := "package demo\n\nfunc A() {\n\tx := 1\n\ty := 2\n\t_, _ = x, y\n}\n"
+	filename := filepath.Join(t.TempDir(), "a.go")
+	if err := os.WriteFile(filename, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	tf := fset.AddFile(filename, -1, len(src))
+	tf.SetLinesForContent([]byte(src))
+	fix := func(old, newText string) FixResult {
+		start := strings.Index(src, old)
+		pos, end := tf.Pos(start), tf.Pos(start+len(old))
+		return FixResult{
+			Agg:   &AggregatedIssue{Filename: filename, Pos: pos, End: end},
+			Edits: []analysis.TextEdit{{Pos: pos, End: end, NewText: []byte(newText)}},
+		}
+	}
+	defer func(s *FixSummary) { Summary = s }(Summary)
+
+	Summary = &FixSummary{}
+	before, after, err := PreviewFile(fset, filename, []FixResult{fix("x := 1", "x := 10"), fix("x := 1\n\ty", "z"), fix("y := 2", "y := 20")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(before) != src || !strings.Contains(string(after), "x := 10") || !strings.Contains(string(after), "y := 20") {
+		t.Fatalf("修复后的内容不对:\n%s", after)
+	}
+	if len(Summary.Applied) != 2 || len(Summary.Failed) != 1 || Summary.Failed[0].Reason != "与同一文件中的其他修复重叠" {
+		t.Fatalf("汇总 = 应用 %d，失败 %+v", len(Summary.Applied), Summary.Failed)
+	}
+
+	Summary = &FixSummary{}
+	_, after, err = PreviewFile(fset, filename, []FixResult{fix("x := 1", "x := 10"), fix("y := 2", "y := (")})
+	if err != nil || after != nil {
+		t.Fatalf("补丁无法应用时应返回 nil: %v, %q", err, after)
+	}
+	if len(Summary.Applied) != 0 || len(Summary.Failed) != 2 {
+		t.Fatalf("汇总 = 应用 %d，失败 %d", len(Summary.Applied), len(Summary.Failed))
+	}
+}
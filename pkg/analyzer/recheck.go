@@ -0,0 +1,104 @@
+package analyzer
+
+import (
+	"fmt"
+	"go/ast"
+	"os"
+	"strings"
+
+	"github.com/hsdaoqi/golint-ai/checkers"
+	"github.com/hsdaoqi/golint-ai/pkg/baseline"
+	"github.com/hsdaoqi/golint-ai/pkg/verifier"
+	"golang.org/x/tools/go/analysis"
+)
+
+// 补丁复查的结论
+const (
+	OutcomeFixed     = "fixed"     // 原缺陷消失，且没有引入新缺陷
+	OutcomeUnchanged = "unchanged" // 原缺陷仍然存在
+	OutcomeRegressed = "regressed" // 补丁范围内出现了新的缺陷
+)
+
+// recheck 在打上补丁的包上重新运行检查器，判断补丁是否真正消除了缺陷
+// patched 为修改后的完整内容，first、last 为补丁在其中所占的行；返回结论与说明。
+// 无法完成复查时返回错误，不能把没有检查过的补丁当作已修复
+func recheck(vr *verifier.Result, agg *AggregatedIssue, patched []byte, first, last int) (string, string, error) {
+	active, err := loadCheckers()
+	if err != nil {
+		return "", "", err
+	}
+	pkg := vr.Package
+	var file *ast.File
+	for _, f := range pkg.Syntax {
+		if verifier.SameFile(pkg.Fset.File(f.Pos()).Name(), agg.Filename) {
+			file = f
+		}
+	}
+	if file == nil {
+		return "", "", fmt.Errorf("包 %s 中找不到 %s", pkg.PkgPath, agg.Filename)
+	}
+	filename := pkg.Fset.File(file.Pos()).Name()
+
+	pass := &analysis.Pass{
+		Fset:       pkg.Fset,
+		Files:      pkg.Syntax,
+		Pkg:        pkg.Types,
+		TypesInfo:  pkg.TypesInfo,
+		TypesSizes: pkg.TypesSizes,
+		Report:     func(analysis.Diagnostic) {}, // 失效屏蔽指令在复查中无需汇报
+		// 检查器从这里读取代码片段，必须返回与语法树一致的补丁后内容，而不是磁盘上的原文件
+		ReadFile: func(name string) ([]byte, error) {
+			if filename == name {
+				return patched, nil
+			}
+			return os.ReadFile(name)
+		},
+	}
+
+	// 按指纹与补丁前的缺陷对照：补丁范围内（按函数修复时是整个函数体）原本就有、但不在本次修复之列的缺陷
+	// （被 --only-categories、策略、基线或变更行过滤掉的）不算补丁引入的
+	targeted := make(map[string]bool)
+	for _, fp := range agg.Fingerprints {
+		targeted[fp] = true
+	}
+	before := make(map[string]int)
+	for _, fp := range agg.existing {
+		before[fp]++
+	}
+
+	var remaining, introduced []string
+	for _, iss := range checkers.ScanFile(pass, file, active) {
+		line := pkg.Fset.Position(iss.Pos).Line
+		if line < first || line > last || !iss.Severity.AtLeast(MinSeverity) {
+			continue
+		}
+		fp := baseline.Fingerprint(iss.Category, enclosingFunc(pass, file, iss.Pos), iss.Snippet)
+		desc := fmt.Sprintf("第 %d 行 [%s] %s", line, iss.Category, iss.Message)
+		switch {
+		case targeted[fp]:
+			remaining = append(remaining, desc)
+		case before[fp] > 0:
+			before[fp]--
+		case containsFold(agg.Categories, iss.Category):
+			remaining = append(remaining, desc) // 代码被改写但缺陷仍在
+		default:
+			introduced = append(introduced, desc)
+		}
+	}
+	switch {
+	case len(introduced) > 0:
+		return OutcomeRegressed, "补丁引入了新的缺陷:\n" + strings.Join(introduced, "\n"), nil
+	case len(remaining) > 0:
+		return OutcomeUnchanged, "补丁没有消除原缺陷:\n" + strings.Join(remaining, "\n"), nil
+	}
+	return OutcomeFixed, "", nil
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,170 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hsdaoqi/golint-ai/pkg/baseline"
+	"github.com/hsdaoqi/golint-ai/pkg/verifier"
+	"golang.org/x/tools/go/packages"
+)
+
+// typeCheck 把 content 当作 filename 的内容解析并做类型检查，模拟 ValidatePatch 通过 overlay 加载出的包
+func typeCheck(t *testing.T, filename string, content []byte) *verifier.Result {
+	t.Helper()
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, content, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &types.Info{
+		Types:      make(map[ast.Expr]types.TypeAndValue),
+		Defs:       make(map[*ast.Ident]types.Object),
+		Uses:       make(map[*ast.Ident]types.Object),
+		Selections: make(map[*ast.SelectorExpr]*types.Selection),
+		Scopes:     make(map[ast.Node]*types.Scope),
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	pkg, err := conf.Check("demo", fset, []*ast.File{f}, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &verifier.Result{Package: &packages.Package{
+		PkgPath:   "demo",
+		Fset:      fset,
+		Syntax:    []*ast.File{f},
+		Types:     pkg,
+		TypesInfo: info,
+	}}
+}
+
+// 补丁让文件变长后，补丁之后的缺陷偏移超出磁盘上原文件的长度，复查不能越界也不能读到错位的片段
+func TestRecheckPatchGrowsFile(t *testing.T) {
+	// 文件超过 512 字节，os.ReadFile 返回的切片容量不再有富余，错位的偏移会直接越界
+	padding := "// " + strings.Repeat("x", 600) + "\n"
+	original := padding + `package demo
+
+import "os"
+
+func A() {
+	f, err := os.Open("x")
+	_ = f
+	_ = err
+	go func() {}()
+}
+`
+	patched := padding + `package demo
+
+import "os"
+
+func A() {
+	f, err := os.Open("x")
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	_ = f
+	go func() {}()
+}
+`
+	filename := filepath.Join(t.TempDir(), "a.go")
+	if err := os.WriteFile(filename, []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+	vr := typeCheck(t, filename, []byte(patched))
+	agg := &AggregatedIssue{Filename: filename, Categories: []string{"UnhandledError", "ResourceLeak"}}
+
+	outcome, msg, err := recheck(vr, agg, []byte(patched), 7, 11)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outcome != OutcomeFixed {
+		t.Fatalf("recheck = %s (%s), want %s", outcome, msg, OutcomeFixed)
+	}
+
+	// 补丁之外的协程泄露仍会被检出，且片段取自补丁后的内容
+	outcome, msg, err = recheck(vr, agg, []byte(patched), 7, 13)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outcome != OutcomeRegressed || !strings.Contains(msg, "GoroutineLeak") {
+		t.Fatalf("recheck = %s (%s), want GoroutineLeak to be reported", outcome, msg)
+	}
+}
+
+// 路径经过符号链接时仍要找到被修改的文件；确实找不到时返回错误，而不是把补丁当作已修复
+func TestRecheckFileLookup(t *testing.T) {
+	content := []byte("package demo\n\nfunc A() {\n\tgo func() {}()\n}\n")
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "a.go")
+	if err := os.WriteFile(filename, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(t.TempDir(), "link")
+	if err := os.Symlink(dir, link); err != nil {
+		t.Skip(err)
+	}
+	vr := typeCheck(t, filename, content)
+
+	agg := &AggregatedIssue{Filename: filepath.Join(link, "a.go"), Categories: []string{"GoroutineLeak"}}
+	outcome, _, err := recheck(vr, agg, content, 4, 4)
+	if err != nil || outcome != OutcomeUnchanged {
+		t.Fatalf("recheck via symlink = %s, %v; want %s", outcome, err, OutcomeUnchanged)
+	}
+
+	agg.Filename = filepath.Join(dir, "missing.go")
+	if outcome, _, err := recheck(vr, agg, content, 4, 4); err == nil {
+		t.Fatalf("recheck of a file outside the package = %s, want an error", outcome)
+	}
+}
+
+// 按函数修复时复查范围是整个函数体：其中原本就有、但没有交给 AI 修复的缺陷不能算作补丁引入的
+func TestRecheckIgnoresPreexistingFindings(t *testing.T) {
+	patched := []byte(`package demo
+
+import "os"
+
+func A() {
+	f, err := os.Open("x")
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	go func() {}()
+}
+`)
+	filename := filepath.Join(t.TempDir(), "a.go")
+	if err := os.WriteFile(filename, patched, 0644); err != nil {
+		t.Fatal(err)
+	}
+	vr := typeCheck(t, filename, patched)
+	unhandled := baseline.Fingerprint("UnhandledError", "demo.A", `f, err := os.Open("x")`)
+	leak := baseline.Fingerprint("GoroutineLeak", "demo.A", "go func() {}()")
+	agg := &AggregatedIssue{
+		Filename:     filename,
+		Categories:   []string{"UnhandledError"},
+		Fingerprints: []string{unhandled},
+		existing:     []string{unhandled, leak},
+	}
+
+	outcome, msg, err := recheck(vr, agg, patched, 5, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outcome != OutcomeFixed {
+		t.Fatalf("recheck = %s (%s), want %s", outcome, msg, OutcomeFixed)
+	}
+
+	// 同一协程泄露若补丁前不存在，就是补丁引入的
+	agg.existing = []string{unhandled}
+	if outcome, _, _ := recheck(vr, agg, patched, 5, 12); outcome != OutcomeRegressed {
+		t.Fatalf("recheck = %s, want %s", outcome, OutcomeRegressed)
+	}
+}
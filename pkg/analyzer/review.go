@@ -0,0 +1,132 @@
+package analyzer
+
+import (
+	"fmt"
+	"go/token"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/hsdaoqi/golint-ai/pkg/udiff"
+)
+
+// 终端颜色，输出不是终端或设置了 NO_COLOR 时不使用
+const (
+	colorReset = "\033[0m"
+	colorRed   = "\033[31m"
+	colorGreen = "\033[32m"
+	colorCyan  = "\033[36m"
+)
+
+// useColor 判断是否向 out 输出彩色内容
+func useColor(out io.Writer) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	f, ok := out.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// showDiff 以带上下文的 diff 展示修复前后的差异，无法生成 diff 时退回展示补丁原文
+func (s *FixSession) showDiff(it *sessionItem) {
+	name := it.res.Agg.Filename
+	content, ok := s.contents[name]
+	if !ok {
+		var err error
+		if content, err = os.ReadFile(name); err != nil {
+			log.Printf("无法读取文件: %v", err)
+		}
+		s.contents[name] = content
+	}
+	newContent, err := FixFile(s.fset, name, content, it.res.Edits)
+	if content == nil || err != nil {
+		fmt.Fprintf(s.out, "\n修复建议: \n%s", it.res.Patch)
+		return
+	}
+
+	diff := udiff.Unified(name, content, newContent)
+	if diff == "" {
+		fmt.Fprint(s.out, "\n修复建议与原代码相同")
+		return
+	}
+	// 文件名已在缺陷位置中展示，只保留 hunk 部分
+	diff = diff[strings.Index(diff, "@@"):]
+	for _, line := range strings.Split(strings.TrimSuffix(diff, "\n"), "\n") {
+		color := ""
+		switch {
+		case !s.color:
+		case strings.HasPrefix(line, "@@"):
+			color = colorCyan
+		case strings.HasPrefix(line, "+"):
+			color = colorGreen
+		case strings.HasPrefix(line, "-"):
+			color = colorRed
+		}
+		if color != "" {
+			line = color + line + colorReset
+		}
+		fmt.Fprint(s.out, "\n"+line)
+	}
+}
+
+// editPatch 在 $EDITOR 中打开补丁，返回编辑后的内容
+func editPatch(patch string) (string, error) {
+	editor := strings.Fields(os.Getenv("EDITOR"))
+	if len(editor) == 0 {
+		editor = []string{"vi"}
+	}
+
+	tmp, err := os.CreateTemp("", "golint-ai-*.go")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(patch + "\n"); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+
+	cmd := exec.Command(editor[0], append(editor[1:], tmp.Name())...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("编辑器 %s 运行失败: %w", editor[0], err)
+	}
+	edited, err := os.ReadFile(tmp.Name())
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(edited), "\n"), nil
+}
+
+// setPatch 用手工编辑的补丁替换当前修复建议。补丁与 AI 生成的一样需要通过编译、复查（与 VerifyTests 时的测试），
+// 不通过时返回原因，当前修复建议保持不变
+func (it *sessionItem) setPatch(fset *token.FileSet, patch string) error {
+	content, err := os.ReadFile(it.res.Agg.Filename)
+	if err != nil {
+		return fmt.Errorf("无法读取文件: %w", err)
+	}
+	edits, outcome, reason, err := checkPatch(fset, it.res.Agg, content, patch)
+	if err != nil {
+		return err
+	}
+	switch {
+	case outcome == OutcomeFixed:
+	case outcome != "":
+		return fmt.Errorf("未通过复查 (%s): %s", outcome, reason)
+	default:
+		return fmt.Errorf("无法通过编译: %s", reason)
+	}
+	it.res.Patch, it.res.Edits = patch, edits
+	it.res.Error, it.res.Outcome = nil, outcome
+	it.res.Attempts, it.edited = 1, true
+	return nil
+}
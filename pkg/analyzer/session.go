@@ -0,0 +1,366 @@
+package analyzer
+
+import (
+	"bufio"
+	"fmt"
+	"go/token"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hsdaoqi/golint-ai/pkg/gitcommit"
+	"github.com/hsdaoqi/golint-ai/pkg/journal"
+	"golang.org/x/tools/go/analysis"
+)
+
+// 会话中每条修复的决定
+type decision int
+
+const (
+	undecided decision = iota
+	accepted
+	rejected
+)
+
+type sessionItem struct {
+	res      FixResult
+	decision decision
+	reason   string // 拒绝或跳过的原因
+	edited   bool   // 补丁经过手工编辑
+}
+
+// FixSession 在所有包分析完成后统一处理修复：先按策略自动决定，再对需要确认的修复逐条交互，
+// 最后按文件一次性写回。交互期间不修改任何文件，因此可以回到前面的缺陷改变主意
+type FixSession struct {
+	fset     *token.FileSet
+	in       *bufio.Reader
+	out      io.Writer
+	color    bool              // 是否输出彩色 diff
+	contents map[string][]byte // 交互期间文件不会被修改，缓存原始内容用于生成 diff
+	items    []*sessionItem
+}
+
+// NewFixSession 创建修复会话，findings 可以来自多个包，但必须共享同一个 FileSet
+func NewFixSession(fset *token.FileSet, findings []FixResult, in io.Reader, out io.Writer) *FixSession {
+	s := &FixSession{
+		fset:     fset,
+		in:       bufio.NewReader(in),
+		out:      out,
+		color:    useColor(out),
+		contents: make(map[string][]byte),
+	}
+	for _, res := range findings {
+		s.items = append(s.items, &sessionItem{res: res})
+	}
+	// 按文件、再按位置排列，交互时自上而下浏览
+	sort.SliceStable(s.items, func(i, j int) bool {
+		a, b := s.items[i].res.Agg, s.items[j].res.Agg
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		return a.Pos < b.Pos
+	})
+	return s
+}
+
+// Run 执行整个会话并把结果记入 Summary，写入失败时返回错误
+func (s *FixSession) Run() error {
+	var pending []*sessionItem
+	for _, it := range s.items {
+		switch {
+		case it.res.Error != nil:
+			Summary.record(s.fset, it.res, "failed", it.res.Error.Error())
+			it.decision = rejected
+		case len(it.res.Edits) == 0:
+			Summary.record(s.fset, it.res, "failed", "AI 未给出补丁")
+			it.decision = rejected
+		case policyFor(it.res.Agg) == PolicyAuto || AssumeYes:
+			if !s.accept(it) {
+				it.decision, it.reason = rejected, "已达到 --max-fixes 上限"
+			}
+		case BatchMode():
+			it.decision, it.reason = rejected, "需要交互确认 (可加 --yes)"
+		default:
+			pending = append(pending, it)
+		}
+	}
+	if len(pending) > 0 {
+		s.interact(pending)
+	}
+	return s.commit()
+}
+
+// accept 在 --max-fixes 名额内接受一条修复
+func (s *FixSession) accept(it *sessionItem) bool {
+	if it.decision == accepted {
+		return true
+	}
+	if MaxFixes > 0 {
+		n := 0
+		for _, other := range s.items {
+			if other.decision == accepted {
+				n++
+			}
+		}
+		if n >= MaxFixes {
+			return false
+		}
+	}
+	it.decision, it.reason = accepted, ""
+	return true
+}
+
+const sessionHelp = `y - 应用此修复
+n - 不应用此修复
+a - 应用此修复及后面所有尚未决定的修复
+s - 跳过此文件中剩余的修复
+e - 在 $EDITOR 中编辑补丁
+r - 请 AI 重新生成一份补丁
+b - 回到上一条修复
+q - 退出，已做出的决定仍然生效
+? - 显示帮助`
+
+// interact 逐条询问 pending 中的修复，读到输入结束时视为 q
+func (s *FixSession) interact(pending []*sessionItem) {
+	for i := 0; i < len(pending); {
+		it := pending[i]
+		s.show(it, i+1, len(pending))
+		fmt.Fprint(s.out, "\n是否应用此修复并写入文件? [y,n,a,s,e,r,b,q,?]: ")
+
+		input, err := s.in.ReadString('\n')
+		cmd := strings.ToLower(strings.TrimSpace(input))
+		if err != nil && cmd == "" {
+			cmd = "q"
+		}
+		switch cmd {
+		case "y":
+			if !s.accept(it) {
+				fmt.Fprintf(s.out, "已达到 --max-fixes 上限 (%d)，可以回到前面拒绝其他修复后再接受此修复\n", MaxFixes)
+				continue
+			}
+			i++
+		case "n":
+			it.decision, it.reason = rejected, "用户拒绝"
+			i++
+		case "a":
+			for _, rest := range pending[i:] {
+				if rest != it && rest.decision != undecided {
+					continue
+				}
+				if !s.accept(rest) {
+					rest.decision, rest.reason = rejected, "已达到 --max-fixes 上限"
+				}
+			}
+			return
+		case "s":
+			file := it.res.Agg.Filename
+			for ; i < len(pending) && pending[i].res.Agg.Filename == file; i++ {
+				if pending[i] == it || pending[i].decision == undecided {
+					pending[i].decision, pending[i].reason = rejected, "用户跳过了整个文件"
+				}
+			}
+		case "e":
+			patch, err := editPatch(it.res.Patch)
+			if err != nil {
+				log.Printf("编辑补丁失败: %v", err)
+				continue
+			}
+			if patch == it.res.Patch {
+				fmt.Fprintln(s.out, "补丁没有变化。")
+				continue
+			}
+			fmt.Fprintln(s.out, "正在校验编辑后的补丁...")
+			if err := it.setPatch(s.fset, patch); err != nil {
+				log.Printf("编辑后的补丁%v，保留原来的补丁", err)
+			}
+		case "r":
+			fmt.Fprintln(s.out, "正在重新生成修复建议...")
+			candidate := FixResult{Agg: it.res.Agg}
+			requestFix(s.fset, &candidate)
+			if candidate.Error != nil {
+				log.Printf("AI 修复失败 [%s]: %v", it.res.Agg.VarName, candidate.Error)
+				continue
+			}
+			it.res, it.edited = candidate, false
+		case "b":
+			if i == 0 {
+				fmt.Fprintln(s.out, "已经是第一条修复。")
+				continue
+			}
+			i--
+		case "q":
+			return
+		default:
+			fmt.Fprintln(s.out, sessionHelp)
+		}
+	}
+}
+
+// show 展示一条修复建议的 diff 及其当前决定
+func (s *FixSession) show(it *sessionItem, index, total int) {
+	fmt.Fprint(s.out, "\n"+strings.Repeat("=", 60))
+	fmt.Fprintf(s.out, "\n[%d/%d] 缺陷位置: %s:%d", index, total, it.res.Agg.Filename, s.fset.Position(it.res.Agg.Pos).Line)
+	fmt.Fprintf(s.out, "\n缺陷类别: %s", strings.Join(it.res.Agg.Categories, " & "))
+	if len(it.res.Agg.Findings) > 0 {
+		fmt.Fprintf(s.out, "\n函数 %s 中的缺陷:\n  %s", it.res.Agg.Function, strings.Join(it.res.Agg.Findings, "\n  "))
+	}
+	switch {
+	case it.edited:
+		fmt.Fprint(s.out, "\n校验结果: 手工编辑的补丁通过编译与复查")
+	case it.res.Attempts > 0:
+		fmt.Fprintf(s.out, "\n校验结果: 第 %d 次尝试通过编译与复查", it.res.Attempts)
+	}
+	switch it.decision {
+	case accepted:
+		fmt.Fprint(s.out, "\n当前决定: 应用")
+	case rejected:
+		fmt.Fprint(s.out, "\n当前决定: 不应用")
+	}
+	fmt.Fprint(s.out, "\n"+strings.Repeat("-", 60))
+	s.showDiff(it)
+	fmt.Fprint(s.out, "\n"+strings.Repeat("-", 60))
+}
+
+// fileFixes 一个文件中将要应用的修复
+type fileFixes struct {
+	name     string
+	original []byte // 修复前的内容
+	final    []byte // 应用全部修复后的内容
+	items    []*sessionItem
+}
+
+// write 以事务写入所有文件。开启 git 集成时先确认可以提交（提交身份、HEAD）再创建分支，
+// 写入后把每个修复单独提交；任何提交失败都会撤销本次的提交并恢复所有文件
+func (s *FixSession) write(tx *journal.Session, staged []fileFixes) error {
+	var head string
+	if gitMode() {
+		if err := gitcommit.CheckIdentity(); err != nil {
+			return err
+		}
+		var err error
+		if head, err = gitcommit.Head(); err != nil {
+			return fmt.Errorf("无法确定当前提交: %w", err)
+		}
+		if GitBranch != "" {
+			if err := gitcommit.CreateBranch(GitBranch); err != nil {
+				return err
+			}
+			fmt.Fprintf(s.out, "已创建并切换到分支 %s\n", GitBranch)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	if gitMode() {
+		if err := s.commitEach(staged); err != nil {
+			return rollbackCommits(tx, head, staged, err)
+		}
+	}
+	Summary.setSession(tx.ID)
+	return nil
+}
+
+// commit 写回所有被接受的修复并记录修复日志，同一文件中互相重叠的修复只保留靠前的一处；
+// 返回写入阶段的错误，此时没有任何修复生效
+func (s *FixSession) commit() error {
+	byFile := make(map[string][]*sessionItem)
+	var files []string
+	for _, it := range s.items {
+		switch it.decision {
+		case accepted:
+			name := it.res.Agg.Filename
+			if _, ok := byFile[name]; !ok {
+				files = append(files, name)
+			}
+			byFile[name] = append(byFile[name], it)
+		case undecided:
+			Summary.record(s.fset, it.res, "skipped", "用户退出前未作决定")
+		default:
+			if it.reason != "" {
+				Summary.record(s.fset, it.res, "skipped", it.reason)
+			}
+		}
+	}
+
+	// 所有文件的新内容先全部算好再作为一个事务写入，任何文件写入失败都会整体回滚
+	tx := journal.Begin()
+	var applied []*sessionItem
+	var staged []fileFixes
+	for _, name := range files {
+		var candidates []*sessionItem
+		last := token.NoPos
+		for _, it := range byFile[name] {
+			if it.res.Agg.Pos < last {
+				Summary.record(s.fset, it.res, "failed", "与同一文件中的其他修复重叠")
+				continue
+			}
+			last = it.res.Agg.End
+			candidates = append(candidates, it)
+		}
+
+		// 被接受的修复合在一起再编译一次，互相冲突时放弃级别较低的修复；此时用户已审阅过补丁，不再合并重修
+		results := make([]FixResult, len(candidates))
+		for k, it := range candidates {
+			results[k] = it.res
+		}
+		original, err := os.ReadFile(name)
+		if err != nil {
+			log.Printf("%s 无法应用修复: %v", name, err)
+			for _, it := range candidates {
+				Summary.record(s.fset, it.res, "failed", err.Error())
+			}
+			continue
+		}
+		results = combineFixes(s.fset, name, original, results, false)
+
+		var edits []analysis.TextEdit
+		var inFile []*sessionItem
+		var fixes []string
+		for k, it := range candidates {
+			if results[k].Error != nil {
+				it.res = results[k]
+				Summary.record(s.fset, it.res, "failed", it.res.Error.Error())
+				continue
+			}
+			edits = append(edits, it.res.Edits...)
+			inFile = append(inFile, it)
+			fixes = append(fixes, fmt.Sprintf("第 %d 行 %s", s.fset.Position(it.res.Agg.Pos).Line,
+				strings.Join(it.res.Agg.Categories, " & ")))
+		}
+		if len(inFile) == 0 {
+			continue
+		}
+
+		content, err := FixFile(s.fset, name, original, edits)
+		if err == nil && gitMode() && !DryRun {
+			err = checkClean(name)
+		}
+		if err == nil {
+			err = tx.Stage(name, content, fixes...)
+		}
+		if err != nil {
+			log.Printf("%s 无法应用修复: %v", name, err)
+			for _, it := range inFile {
+				Summary.record(s.fset, it.res, "failed", err.Error())
+			}
+			continue
+		}
+		applied = append(applied, inFile...)
+		staged = append(staged, fileFixes{name: name, original: original, final: content, items: inFile})
+	}
+
+	status, reason := "applied", ""
+	var err error
+	if !DryRun && len(applied) > 0 {
+		if err = s.write(tx, staged); err != nil {
+			log.Printf("写入失败: %v", err)
+			status, reason = "failed", err.Error()
+		}
+	}
+	for _, it := range applied {
+		Summary.record(s.fset, it.res, status, reason)
+	}
+	return err
+}
@@ -0,0 +1,97 @@
+package baseline
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Version 基线文件格式版本
+const Version = 1
+
+// Entry 基线中记录的一条已知缺陷
+type Entry struct {
+	Fingerprint string `json:"fingerprint"`
+	Category    string `json:"category"`
+	Function    string `json:"function,omitempty"`
+	File        string `json:"file,omitempty"` // 仅供阅读，不参与匹配
+	Message     string `json:"message,omitempty"`
+}
+
+// Baseline 一份基线，匹配时按指纹计数消耗，同一指纹出现多少次就抵消多少条
+type Baseline struct {
+	Version int     `json:"version"`
+	Entries []Entry `json:"findings"`
+
+	mu        sync.Mutex
+	remaining map[string]int
+}
+
+// Fingerprint 计算缺陷的稳定指纹：类别 + 所在函数 + 归一化后的代码片段
+// 不使用行号或 token.Pos，无关代码的增删不会让已有缺陷"变成新的"
+func Fingerprint(category, function, snippet string) string {
+	normalized := strings.Join(strings.Fields(snippet), " ")
+	sum := sha256.Sum256([]byte(category + "\x00" + function + "\x00" + normalized))
+	return hex.EncodeToString(sum[:16])
+}
+
+// Load 读取基线文件
+func Load(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取基线文件失败: %w", err)
+	}
+	b := &Baseline{}
+	if err := json.Unmarshal(data, b); err != nil {
+		return nil, fmt.Errorf("解析基线文件 %s 失败: %w", path, err)
+	}
+	if b.Version != Version {
+		return nil, fmt.Errorf("不支持的基线文件版本 %d", b.Version)
+	}
+	return b, nil
+}
+
+// Write 将基线按指纹排序后写入文件，保证重复生成时内容稳定
+func (b *Baseline) Write(path string) error {
+	b.Version = Version
+	sort.SliceStable(b.Entries, func(i, j int) bool {
+		if b.Entries[i].File != b.Entries[j].File {
+			return b.Entries[i].File < b.Entries[j].File
+		}
+		return b.Entries[i].Fingerprint < b.Entries[j].Fingerprint
+	})
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Add 记录一条缺陷
+func (b *Baseline) Add(e Entry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.Entries = append(b.Entries, e)
+}
+
+// Match 判断指纹是否在基线中，命中时消耗一次计数（并发安全）
+func (b *Baseline) Match(fingerprint string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.remaining == nil {
+		b.remaining = make(map[string]int, len(b.Entries))
+		for _, e := range b.Entries {
+			b.remaining[e.Fingerprint]++
+		}
+	}
+	if b.remaining[fingerprint] == 0 {
+		return false
+	}
+	b.remaining[fingerprint]--
+	return true
+}
@@ -0,0 +1,60 @@
+package baseline
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestFingerprint(t *testing.T) {
+	type finding struct{ category, function, snippet string }
+	base := finding{"UnhandledError", "demo.A", `f, err := os.Open("x")`}
+	tests := []struct {
+		name  string
+		other finding
+		same  bool
+	}{
+		{"完全相同", base, true},
+		{"缩进与换行不同", finding{base.category, base.function, "\t\tf, err :=\n\t\t\tos.Open(\"x\")  "}, true},
+		{"类别不同", finding{"ResourceLeak", base.function, base.snippet}, false},
+		{"函数不同", finding{base.category, "demo.B", base.snippet}, false},
+		{"代码不同", finding{base.category, base.function, `f, err := os.Open("y")`}, false},
+		{"字段边界不同", finding{"UnhandledErrordemo.A", "", base.snippet}, false},
+	}
+	want := Fingerprint(base.category, base.function, base.snippet)
+	if len(want) != 32 {
+		t.Fatalf("指纹长度 = %d, want 32", len(want))
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Fingerprint(tt.other.category, tt.other.function, tt.other.snippet)
+			if (got == want) != tt.same {
+				t.Fatalf("Fingerprint(%q, %q, %q) 与基准相同 = %v, want %v",
+					tt.other.category, tt.other.function, tt.other.snippet, got == want, tt.same)
+			}
+		})
+	}
+}
+
+// 同一指纹在基线中出现几次就只抵消几条，多出来的是新增缺陷
+func TestMatchConsumesEntries(t *testing.T) {
+	fp := Fingerprint("GoroutineLeak", "demo.A", "go func() {}()")
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	b := &Baseline{}
+	b.Add(Entry{Fingerprint: fp, Category: "GoroutineLeak"})
+	b.Add(Entry{Fingerprint: fp, Category: "GoroutineLeak"})
+	if err := b.Write(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []bool{true, true, false} {
+		if got := loaded.Match(fp); got != want {
+			t.Fatalf("第 %d 次 Match = %v, want %v", i+1, got, want)
+		}
+	}
+	if loaded.Match(Fingerprint("GoroutineLeak", "demo.B", "go func() {}()")) {
+		t.Fatal("不在基线中的指纹不应命中")
+	}
+}
@@ -4,17 +4,47 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
 type Config struct {
 	AI struct {
+		Enabled    bool   `mapstructure:"enabled"` // 为 false 时只做检测，不请求 LLM
 		APIKey     string `mapstructure:"api_key"`
 		APIURL     string `mapstructure:"api_url"`
 		Model      string `mapstructure:"model"`
 		MaxRetries int    `mapstructure:"max_retries"`
 	} `mapstructure:"ai"`
+
+	// Checkers 控制启用哪些规则以及每条规则的参数
+	Checkers struct {
+		Enable   []string                          `mapstructure:"enable"`
+		Disable  []string                          `mapstructure:"disable"`
+		Settings map[string]map[string]interface{} `mapstructure:"settings"`
+	} `mapstructure:"checkers"`
+
+	// Analysis 控制哪些文件参与分析
+	Analysis struct {
+		SkipDirs    []string `mapstructure:"skip_dirs"`    // 跳过的目录名或相对路径
+		IgnoreTests bool     `mapstructure:"ignore_tests"` // 跳过 _test.go
+		IgnoreFile  string   `mapstructure:"ignore_file"`  // gitignore 风格的忽略文件
+	} `mapstructure:"analysis"`
+
+	// Verify 控制补丁校验时对 go 命令的限制；校验会编译甚至运行 AI 生成的代码，这些限制不构成安全隔离
+	Verify struct {
+		Timeout    time.Duration `mapstructure:"timeout"`     // 单次编译或测试的时长上限
+		CPUSeconds int           `mapstructure:"cpu_seconds"` // 每个进程的 CPU 时间上限，0 表示不限
+		MemoryMB   int           `mapstructure:"memory_mb"`   // 每个进程的虚拟内存上限，0 表示不限（-race 时不生效）
+		AllowCgo   bool          `mapstructure:"allow_cgo"`   // 是否允许 cgo（-race 需要 cgo，会自动开启）
+		GoCache    string        `mapstructure:"gocache"`     // 校验专用的持久 GOCACHE，为空时每次运行使用全新的临时缓存
+	} `mapstructure:"verify"`
+
+	// Fix 控制 fix 命令对各类缺陷的处理方式
+	Fix struct {
+		Policy map[string]string `mapstructure:"policy"` // 类别 -> auto/ask/never，未配置的类别为 ask
+	} `mapstructure:"fix"`
 }
 
 var (
@@ -38,6 +68,12 @@ func Load() *Config {
 		// 将 YAML 中的 ai.api_key 映射为环境变量 GOLINT_AI_API_KEY
 		// Viper 默认不处理点号到下划线的转换，需要手动设置
 		viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+		viper.SetDefault("ai.enabled", true)
+		viper.SetDefault("analysis.ignore_file", ".golintaiignore")
+		viper.SetDefault("verify.timeout", "2m")
+		viper.SetDefault("verify.cpu_seconds", 600)
+		viper.SetDefault("verify.memory_mb", 4096)
+		viper.SetDefault("verify.gocache", "")
 
 		// 4. 读取配置文件（如果不存在也行，因为可能全靠环境变量）
 		if err := viper.ReadInConfig(); err != nil {
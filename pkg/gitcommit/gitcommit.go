@@ -0,0 +1,101 @@
+package gitcommit
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/hsdaoqi/golint-ai/pkg/gitdiff"
+)
+
+// 只操作本地仓库：不会 fetch、push 或访问任何远端
+
+// Dirty 判断文件在工作区或暂存区中是否有未提交的修改（含未跟踪），这样的文件无法把修复单独提交
+func Dirty(path string) (bool, error) {
+	out, err := git(filepath.Dir(path), "status", "--porcelain", "--", filepath.Base(path))
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(out) != "", nil
+}
+
+// CreateBranch 从当前 HEAD 创建并切换到新分支，工作区中的改动会带到新分支上
+func CreateBranch(name string) error {
+	root, err := gitdiff.Root()
+	if err != nil {
+		return err
+	}
+	if _, err := git(root, "check-ref-format", "--branch", name); err != nil {
+		return fmt.Errorf("非法的分支名 %q", name)
+	}
+	_, err = git(root, "switch", "-c", name)
+	return err
+}
+
+// CheckIdentity 确认已配置作者与提交者身份 (user.name/user.email)，避免写入文件之后 git commit 才失败
+func CheckIdentity() error {
+	root, err := gitdiff.Root()
+	if err != nil {
+		return err
+	}
+	for _, v := range []string{"GIT_AUTHOR_IDENT", "GIT_COMMITTER_IDENT"} {
+		if _, err := git(root, "var", v); err != nil {
+			return fmt.Errorf("未配置 git 提交身份，请设置 user.name 与 user.email: %w", err)
+		}
+	}
+	return nil
+}
+
+// Head 返回当前 HEAD 的完整哈希，仓库还没有任何提交时返回错误
+func Head() (string, error) {
+	root, err := gitdiff.Root()
+	if err != nil {
+		return "", err
+	}
+	head, err := git(root, "rev-parse", "--verify", "HEAD")
+	return strings.TrimSpace(head), err
+}
+
+// Rewind 把当前分支移回 rev，并把 paths 在暂存区中的内容恢复为 rev 中的版本；工作区与其他文件的暂存改动不受影响。
+// 用于提交中途失败时撤销本次生成的提交
+func Rewind(rev string, paths []string) error {
+	root, err := gitdiff.Root()
+	if err != nil {
+		return err
+	}
+	if _, err := git(root, "reset", "--quiet", "--soft", rev); err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if _, err := git(filepath.Dir(path), "reset", "--quiet", rev, "--", filepath.Base(path)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Commit 只提交 path 的当前内容（不影响暂存区中的其他改动），返回新提交的短哈希
+func Commit(path, message string) (string, error) {
+	dir := filepath.Dir(path)
+	if _, err := git(dir, "add", "--", filepath.Base(path)); err != nil {
+		return "", err
+	}
+	if _, err := git(dir, "commit", "--quiet", "-m", message, "--", filepath.Base(path)); err != nil {
+		return "", err
+	}
+	hash, err := git(dir, "rev-parse", "--short", "HEAD")
+	return strings.TrimSpace(hash), err
+}
+
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s 失败: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
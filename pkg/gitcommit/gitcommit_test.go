@@ -0,0 +1,96 @@
+package gitcommit
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newRepo 在临时目录中创建只有一个提交的仓库并切换到该目录，不读取用户的全局 git 配置
+func newRepo(t *testing.T, identity bool) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git 不可用")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	for _, v := range []string{"GIT_AUTHOR_NAME", "GIT_AUTHOR_EMAIL", "GIT_COMMITTER_NAME", "GIT_COMMITTER_EMAIL", "EMAIL"} {
+		t.Setenv(v, "")
+		os.Unsetenv(v)
+	}
+	dir := t.TempDir()
+	run(t, dir, "init", "-q")
+	run(t, dir, "config", "user.useConfigOnly", "true")
+	if identity {
+		run(t, dir, "config", "user.name", "test")
+		run(t, dir, "config", "user.email", "test@example.com")
+		for _, name := range []string{"a.txt", "b.txt"} {
+			writeFile(t, filepath.Join(dir, name), "1\n")
+		}
+		run(t, dir, "add", ".")
+		run(t, dir, "commit", "-q", "-m", "init")
+	}
+	t.Chdir(dir)
+	return dir
+}
+
+func run(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := git(dir, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.TrimSpace(out)
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckIdentity(t *testing.T) {
+	newRepo(t, false)
+	if err := CheckIdentity(); err == nil {
+		t.Fatal("没有配置提交身份时 CheckIdentity 应返回错误")
+	}
+	newRepo(t, true)
+	if err := CheckIdentity(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 提交中途失败后，Rewind 撤销本次的提交并取消暂存修复的文件，用户在其他文件上暂存的改动保持不变
+func TestRewind(t *testing.T) {
+	dir := newRepo(t, true)
+	a, b := filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt")
+	head, err := Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, b, "用户暂存的改动\n")
+	run(t, dir, "add", "b.txt")
+
+	writeFile(t, a, "2\n")
+	if _, err := Commit(a, "第一个修复"); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, a, "3\n")
+	run(t, dir, "add", "a.txt") // 模拟 git add 成功、git commit 失败
+
+	if err := Rewind(head, []string{a}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := Head(); got != head {
+		t.Fatalf("HEAD = %s, want %s", got, head)
+	}
+	if staged := run(t, dir, "diff", "--cached", "--name-only"); staged != "b.txt" {
+		t.Fatalf("暂存区中的文件 = %q, want b.txt", staged)
+	}
+	if dirty, err := Dirty(a); err != nil || !dirty {
+		t.Fatalf("a.txt 的工作区内容应保持不变，由修复日志负责恢复: dirty=%v, err=%v", dirty, err)
+	}
+}
@@ -0,0 +1,187 @@
+package gitdiff
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// LineRange 新文件中的一段行号区间（闭区间）
+type LineRange struct {
+	Start, End int
+}
+
+// Changes 记录每个文件（绝对路径）中发生变更的行
+type Changes map[string][]LineRange
+
+var hunkHeader = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,(\d+))? @@`)
+
+// Root 返回当前目录所在 git 仓库的根目录
+func Root() (string, error) {
+	root, err := gitOutput("rev-parse", "--show-toplevel")
+	return strings.TrimSpace(root), err
+}
+
+// FromRev 读取工作区相对于 rev 的本地 git diff，未跟踪的新文件整体视为变更
+func FromRev(rev string) (Changes, error) {
+	root, err := Root()
+	if err != nil {
+		return nil, err
+	}
+
+	// 显式指定前缀，不受用户的 diff.mnemonicPrefix、diff.noprefix 配置影响
+	diff, err := gitOutput("-C", root, "diff", "--no-color", "--no-ext-diff", "--src-prefix=a/", "--dst-prefix=b/", "-U0", rev, "--")
+	if err != nil {
+		return nil, err
+	}
+	changes, err := Parse(strings.NewReader(diff), root)
+	if err != nil {
+		return nil, err
+	}
+
+	untracked, err := gitOutput("-C", root, "ls-files", "-z", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range strings.Split(untracked, "\x00") {
+		if name != "" {
+			changes.addWholeFile(filepath.Join(root, filepath.FromSlash(name)))
+		}
+	}
+	return changes, nil
+}
+
+// FromPatch 读取 unified diff 补丁文件，路径相对于 git 仓库根目录（不在仓库中时相对于当前目录）
+func FromPatch(path string) (Changes, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取补丁文件失败: %w", err)
+	}
+	defer f.Close()
+
+	root, err := Root()
+	if err != nil {
+		if root, err = os.Getwd(); err != nil {
+			return nil, err
+		}
+	}
+	return Parse(f, root)
+}
+
+// dstPrefixes git 新文件一侧可能使用的前缀：默认的 b/，以及 diff.mnemonicPrefix 下的 w/、i/、2/
+var dstPrefixes = []string{"b/", "w/", "i/", "2/"}
+
+// Parse 解析 unified diff，只关心新文件一侧的行号
+// 文件名可以带有任意 git 前缀或不带前缀（--no-prefix），也可以是 git 对特殊字符转义后加引号的形式
+func Parse(r io.Reader, root string) (Changes, error) {
+	changes := make(Changes)
+	var current, oldName string
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "--- "):
+			oldName = headerName(strings.TrimPrefix(line, "--- "))
+		case strings.HasPrefix(line, "+++ "):
+			name := headerName(strings.TrimPrefix(line, "+++ "))
+			if name == "/dev/null" {
+				current = "" // 文件被删除
+				continue
+			}
+			current = filepath.Join(root, filepath.FromSlash(stripPrefix(oldName, name)))
+		case strings.HasPrefix(line, "@@") && current != "":
+			m := hunkHeader.FindStringSubmatch(line)
+			if m == nil {
+				return nil, fmt.Errorf("无法解析 hunk 头: %s", line)
+			}
+			start, _ := strconv.Atoi(m[1])
+			count := 1
+			if m[2] != "" {
+				count, _ = strconv.Atoi(m[2])
+			}
+			if count == 0 {
+				// 纯删除：发生在 start 行之后，视为影响相邻的两行
+				changes[current] = append(changes[current], LineRange{Start: start, End: start + 1})
+				continue
+			}
+			changes[current] = append(changes[current], LineRange{Start: start, End: start + count - 1})
+		}
+	}
+	return changes, scanner.Err()
+}
+
+// headerName 从 ---/+++ 行中取出文件名：去掉引号与转义，以及 diff -u 附带的时间戳
+func headerName(s string) string {
+	if strings.HasPrefix(s, `"`) {
+		if quoted, err := strconv.QuotedPrefix(s); err == nil {
+			if name, err := strconv.Unquote(quoted); err == nil {
+				return name
+			}
+		}
+	}
+	if i := strings.IndexByte(s, '\t'); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s)
+}
+
+// stripPrefix 去掉新文件名的前缀：新旧文件名相同说明没有前缀，去掉首段后相同说明两侧各有一个前缀
+// （a/ 与 b/、c/ 与 w/ 等）；新增或改名的文件无从对照，只去掉 git 已知的前缀
+func stripPrefix(oldName, name string) string {
+	if oldName == name {
+		return name
+	}
+	i := strings.IndexByte(name, '/')
+	if i < 0 {
+		return name
+	}
+	if j := strings.IndexByte(oldName, '/'); j >= 0 && oldName != "/dev/null" && oldName[j:] == name[i:] {
+		return name[i+1:]
+	}
+	for _, prefix := range dstPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return name[len(prefix):]
+		}
+	}
+	return name
+}
+
+// Overlaps 判断 file 的 [startLine, endLine] 是否与变更行有交集
+func (c Changes) Overlaps(file string, startLine, endLine int) bool {
+	ranges, ok := c[filepath.Clean(file)]
+	if !ok {
+		if resolved, err := filepath.EvalSymlinks(file); err == nil {
+			ranges = c[resolved]
+		}
+	}
+	for _, r := range ranges {
+		if startLine <= r.End && endLine >= r.Start {
+			return true
+		}
+	}
+	return false
+}
+
+func (c Changes) addWholeFile(path string) {
+	c[path] = append(c[path], LineRange{Start: 1, End: int(^uint(0) >> 1)})
+}
+
+func gitOutput(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s 失败: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,136 @@
+package gitdiff
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	const root = "/repo"
+	tests := []struct {
+		name string
+		diff string
+		want Changes
+	}{
+		{
+			name: "默认前缀",
+			diff: "diff --git a/f.go b/f.go\n--- a/f.go\n+++ b/f.go\n@@ -3,2 +3,4 @@\n",
+			want: Changes{"/repo/f.go": {{3, 6}}},
+		},
+		{
+			name: "mnemonicPrefix",
+			diff: "diff --git i/pkg/f.go w/pkg/f.go\n--- i/pkg/f.go\n+++ w/pkg/f.go\n@@ -1 +1 @@\n",
+			want: Changes{"/repo/pkg/f.go": {{1, 1}}},
+		},
+		{
+			name: "no-prefix",
+			diff: "diff --git b/f.go b/f.go\n--- b/f.go\n+++ b/f.go\n@@ -10,0 +11,2 @@\n",
+			want: Changes{"/repo/b/f.go": {{11, 12}}},
+		},
+		{
+			name: "新文件",
+			diff: "--- /dev/null\n+++ w/new.go\n@@ -0,0 +1,3 @@\n",
+			want: Changes{"/repo/new.go": {{1, 3}}},
+		},
+		{
+			name: "删除的文件",
+			diff: "--- a/old.go\n+++ /dev/null\n@@ -1,3 +0,0 @@\n",
+			want: Changes{},
+		},
+		{
+			name: "纯删除",
+			diff: "--- a/f.go\n+++ b/f.go\n@@ -5,2 +4,0 @@\n",
+			want: Changes{"/repo/f.go": {{4, 5}}},
+		},
+		{
+			name: "带引号与转义的文件名",
+			diff: "--- \"a/dir/sp\\303\\251cial\\tname.go\"\n+++ \"b/dir/sp\\303\\251cial\\tname.go\"\n@@ -1 +1,2 @@\n",
+			want: Changes{"/repo/dir/spécial\tname.go": {{1, 2}}},
+		},
+		{
+			name: "diff -u 的时间戳",
+			diff: "--- old/f.go\t2024-01-01 00:00:00\n+++ new/f.go\t2024-01-02 00:00:00\n@@ -1,2 +1,3 @@\n",
+			want: Changes{"/repo/f.go": {{1, 3}}},
+		},
+		{
+			name: "多个文件",
+			diff: "--- a/a.go\n+++ b/a.go\n@@ -1 +1 @@\n@@ -9 +9,2 @@\n--- a/b.go\n+++ b/b.go\n@@ -2 +2 @@\n",
+			want: Changes{"/repo/a.go": {{1, 1}, {9, 10}}, "/repo/b.go": {{2, 2}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(strings.NewReader(tt.diff), root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := make(Changes)
+			for name, ranges := range tt.want {
+				want[filepath.FromSlash(name)] = ranges
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("Parse = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestParseBadHunk(t *testing.T) {
+	if _, err := Parse(strings.NewReader("--- a/f.go\n+++ b/f.go\n@@ bad @@\n"), "/repo"); err == nil {
+		t.Fatal("Parse 应当拒绝无法解析的 hunk 头")
+	}
+}
+
+// 用户开启了 diff.mnemonicPrefix 或 diff.noprefix 时，FromRev 得到的路径也要正确
+func TestFromRevIgnoresPrefixConfig(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git 不可用")
+	}
+	for _, cfg := range []string{"diff.mnemonicPrefix", "diff.noprefix"} {
+		t.Run(cfg, func(t *testing.T) {
+			dir := t.TempDir()
+			git := func(args ...string) {
+				t.Helper()
+				cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+				if out, err := cmd.CombinedOutput(); err != nil {
+					t.Fatalf("git %v: %v\n%s", args, err, out)
+				}
+			}
+			write := func(name, content string) {
+				t.Helper()
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			git("init", "-q")
+			git("config", "user.email", "test@example.com")
+			git("config", "user.name", "test")
+			git("config", cfg, "true")
+			write("f.txt", "1\n2\n3\n")
+			git("add", ".")
+			git("commit", "-q", "-m", "init")
+			write("f.txt", "1\nchanged\n3\n")
+			write("new file.txt", "x\n")
+
+			t.Chdir(dir)
+			changes, err := FromRev("HEAD")
+			if err != nil {
+				t.Fatal(err)
+			}
+			root, err := Root()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !changes.Overlaps(filepath.Join(root, "f.txt"), 2, 2) || changes.Overlaps(filepath.Join(root, "f.txt"), 1, 1) {
+				t.Errorf("f.txt 的变更行不对: %v", changes)
+			}
+			if !changes.Overlaps(filepath.Join(root, "new file.txt"), 1, 1) {
+				t.Errorf("未跟踪的文件应整体视为变更: %v", changes)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+// Package golangci 是 golint-ai 的 golangci-lint 模块插件入口。
+//
+// 插件只暴露 checkers 包中的检测规则，只汇报、不调用 AI 修复。
+// 在 .custom-gcl.yml 中引入本模块后，于 .golangci.yml 里这样启用：
+//
+//	linters:
+//	  enable:
+//	    - golint-ai
+//	  settings:
+//	    custom:
+//	      golint-ai:
+//	        type: module
+//	        settings:
+//	          disable: [goroutineleak]
+//	          settings:
+//	            hardcodedsecret:
+//	              pattern: "(?i)(password|token)"
+package golangci
+
+import (
+	"github.com/golangci/plugin-module-register/register"
+	"github.com/hsdaoqi/golint-ai/checkers"
+	"golang.org/x/tools/go/analysis"
+)
+
+func init() {
+	register.Plugin("golint-ai", New)
+}
+
+// Settings 对应 .golangci.yml 中插件的 settings 段，字段含义与 config.yaml 的 checkers 段一致
+type Settings struct {
+	Enable   []string                          `json:"enable"`
+	Disable  []string                          `json:"disable"`
+	Settings map[string]map[string]interface{} `json:"settings"`
+}
+
+// Plugin 实现 register.LinterPlugin
+type Plugin struct {
+	settings Settings
+}
+
+// New 解析 golangci-lint 传入的配置并构造插件
+func New(conf any) (register.LinterPlugin, error) {
+	s, err := register.DecodeSettings[Settings](conf)
+	if err != nil {
+		return nil, err
+	}
+	return &Plugin{settings: s}, nil
+}
+
+// BuildAnalyzers 应用规则配置，为每条启用的规则返回一个独立的 Analyzer
+func (p *Plugin) BuildAnalyzers() ([]*analysis.Analyzer, error) {
+	for name, settings := range p.settings.Settings {
+		if err := checkers.Configure(name, settings); err != nil {
+			return nil, err
+		}
+	}
+
+	active, err := checkers.Select(p.settings.Enable, p.settings.Disable)
+	if err != nil {
+		return nil, err
+	}
+
+	var analyzers []*analysis.Analyzer
+	for _, c := range active {
+		analyzers = append(analyzers, checkers.NewAnalyzer(c))
+	}
+	return analyzers, nil
+}
+
+// GetLoadMode 所有规则都依赖类型信息
+func (p *Plugin) GetLoadMode() string {
+	return register.LoadModeTypesInfo
+}
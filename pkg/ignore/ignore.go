@@ -0,0 +1,144 @@
+package ignore
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// DefaultFile 默认的忽略文件名
+const DefaultFile = ".golintaiignore"
+
+type pattern struct {
+	re      *regexp.Regexp
+	negate  bool // 以 ! 开头，重新包含
+	dirOnly bool // 以 / 结尾，只匹配目录
+}
+
+// Matcher 按 gitignore 语法匹配相对路径
+type Matcher struct {
+	patterns []pattern
+}
+
+// Load 读取忽略文件，文件不存在时返回空的 Matcher
+func Load(filename string) (*Matcher, error) {
+	f, err := os.Open(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Matcher{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return New(lines), nil
+}
+
+// New 由若干行 gitignore 风格的规则构造 Matcher
+func New(lines []string) *Matcher {
+	m := &Matcher{}
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p := pattern{}
+		if strings.HasPrefix(line, "!") {
+			p.negate, line = true, line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			p.dirOnly, line = true, strings.TrimSuffix(line, "/")
+		}
+		// 不含 / 的规则匹配任意层级的同名文件或目录；含 / 的规则相对于忽略文件所在目录
+		anchored := strings.Contains(line, "/")
+		line = strings.TrimPrefix(line, "/")
+		expr := globToRegexp(line)
+		if !anchored {
+			expr = "(?:.*/)?" + expr
+		}
+		re, err := regexp.Compile("^" + expr + "$")
+		if err != nil {
+			continue // 非法的字符类，按 git 的做法直接忽略该行
+		}
+		p.re = re
+		m.patterns = append(m.patterns, p)
+	}
+	return m
+}
+
+// Match 判断斜杠分隔的相对路径是否被忽略；父目录被忽略时其中的文件也被忽略
+func (m *Matcher) Match(rel string) bool {
+	if m == nil || len(m.patterns) == 0 {
+		return false
+	}
+	rel = strings.TrimPrefix(path.Clean(rel), "./")
+	parts := strings.Split(rel, "/")
+	for i := 1; i < len(parts); i++ {
+		if m.matchOne(strings.Join(parts[:i], "/"), true) {
+			return true
+		}
+	}
+	return m.matchOne(rel, false)
+}
+
+func (m *Matcher) matchOne(rel string, isDir bool) bool {
+	ignored := false
+	for _, p := range m.patterns {
+		if p.dirOnly && !isDir {
+			continue
+		}
+		if p.re.MatchString(rel) {
+			ignored = !p.negate
+		}
+	}
+	return ignored
+}
+
+// globToRegexp 将 gitignore 通配符转换为正则：* 不跨目录，** 跨任意层目录
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				i++
+				if i+1 < len(glob) && glob[i+1] == '/' {
+					i++
+					b.WriteString("(?:.*/)?")
+				} else {
+					b.WriteString(".*")
+				}
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			if j := strings.IndexByte(glob[i:], ']'); j > 0 {
+				class := glob[i+1 : i+j]
+				if strings.HasPrefix(class, "!") {
+					class = "^" + class[1:]
+				}
+				b.WriteString("[" + class + "]")
+				i += j
+			} else {
+				b.WriteString(`\[`)
+			}
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,59 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		path     string
+		want     bool
+	}{
+		{"空规则", nil, "a.go", false},
+		{"注释与空行", []string{"# a.go", ""}, "a.go", false},
+		{"任意层级的文件名", []string{"gen.go"}, "pkg/x/gen.go", true},
+		{"星号不跨目录", []string{"pkg/*.go"}, "pkg/x/a.go", false},
+		{"星号匹配同级文件", []string{"pkg/*.go"}, "pkg/a.go", true},
+		{"双星号跨目录", []string{"pkg/**/*.pb.go"}, "pkg/a/b/c.pb.go", true},
+		{"双星号匹配零层目录", []string{"pkg/**/*.pb.go"}, "pkg/c.pb.go", true},
+		{"开头的斜杠锚定根目录", []string{"/vendor"}, "sub/vendor/a.go", false},
+		{"锚定的目录忽略其中文件", []string{"/vendor"}, "vendor/x/a.go", true},
+		{"目录规则忽略其中文件", []string{"testdata/"}, "pkg/testdata/a.go", true},
+		{"目录规则不匹配同名文件", []string{"testdata/"}, "pkg/testdata", false},
+		{"问号匹配单个字符", []string{"a?.go"}, "ab.go", true},
+		{"字符类", []string{"[ab].go"}, "b.go", true},
+		{"否定字符类", []string{"[!ab].go"}, "b.go", false},
+		{"感叹号重新包含", []string{"*.go", "!keep.go"}, "keep.go", false},
+		{"后面的规则优先", []string{"!keep.go", "*.go"}, "keep.go", true},
+		{"./ 前缀", []string{"/a.go"}, "./a.go", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := New(tt.patterns).Match(tt.path); got != tt.want {
+				t.Fatalf("New(%q).Match(%q) = %v, want %v", tt.patterns, tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	m, err := Load(filepath.Join(t.TempDir(), DefaultFile))
+	if err != nil || m.Match("a.go") {
+		t.Fatalf("忽略文件不存在时应返回空的 Matcher: %v", err)
+	}
+
+	filename := filepath.Join(t.TempDir(), DefaultFile)
+	if err := os.WriteFile(filename, []byte("# 生成代码\n*_gen.go  \r\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if m, err = Load(filename); err != nil {
+		t.Fatal(err)
+	}
+	if !m.Match("pkg/a_gen.go") || m.Match("pkg/a.go") {
+		t.Fatal("Load 读到的规则与文件内容不符")
+	}
+}
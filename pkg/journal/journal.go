@@ -0,0 +1,278 @@
+package journal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hsdaoqi/golint-ai/pkg/gitdiff"
+)
+
+// DirName 修复日志所在的目录，位于 git 仓库根目录（不在仓库中时为当前目录）下
+const DirName = ".golint-ai/journal"
+
+// FileChange 一个文件在一次修复会话中的改动
+type FileChange struct {
+	Path        string      `json:"path"`         // 绝对路径
+	Mode        os.FileMode `json:"mode"`         // 原文件权限
+	Before      []byte      `json:"before"`       // 修改前的完整内容
+	AfterSHA256 string      `json:"after_sha256"` // 修改后内容的哈希，撤销前据此判断文件是否又被改动过
+	Fixes       []string    `json:"fixes"`        // 该文件中应用的修复
+}
+
+// Session 一次 fix 运行写入的所有文件，可整体撤销
+type Session struct {
+	ID      string       `json:"id"`
+	Time    time.Time    `json:"time"`
+	Files   []FileChange `json:"files"`
+	Undone  *time.Time   `json:"undone,omitempty"` // 已撤销的时间
+	staged  map[string][]byte
+	written []string
+}
+
+// Begin 开始一次修复会话，文件先暂存，Commit 时才写入
+func Begin() *Session {
+	return &Session{Time: time.Now(), staged: make(map[string][]byte)}
+}
+
+// Stage 暂存一个文件的新内容，fixes 为该文件中应用的修复说明
+func (s *Session) Stage(path string, content []byte, fixes ...string) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	before, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("无法读取文件: %w", err)
+	}
+	s.Files = append(s.Files, FileChange{
+		Path:        path,
+		Mode:        info.Mode().Perm(),
+		Before:      before,
+		AfterSHA256: hash(content),
+		Fixes:       fixes,
+	})
+	s.staged[path] = content
+	return nil
+}
+
+// Commit 先写日志，再以临时文件加重命名的方式逐个替换文件；任何一个文件写入失败都会把已写入的文件恢复原状
+func (s *Session) Commit() error {
+	if len(s.Files) == 0 {
+		return nil
+	}
+	dir, err := Dir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("创建日志目录失败: %w", err)
+	}
+	// 日志只属于本地工作区，不应被提交
+	if err := os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("*\n"), 0644); err != nil {
+		return fmt.Errorf("创建日志目录失败: %w", err)
+	}
+	s.ID = newID(dir, s.Time)
+	if err := s.save(dir); err != nil {
+		return err
+	}
+
+	for _, fc := range s.Files {
+		if err := WriteFile(fc.Path, s.staged[fc.Path], fc.Mode); err != nil {
+			if rollbackErr := s.Rollback(); rollbackErr != nil {
+				return fmt.Errorf("写入 %s 失败: %v；回滚同样失败: %w", fc.Path, err, rollbackErr)
+			}
+			return fmt.Errorf("写入 %s 失败，已回滚本次所有修改: %w", fc.Path, err)
+		}
+		s.written = append(s.written, fc.Path)
+	}
+	return nil
+}
+
+// Rollback 撤销本次事务：恢复已写入的文件并删除日志记录。
+// 除 Commit 自身失败外，也用于文件写入之后的步骤（如 git 提交）失败时
+func (s *Session) Rollback() error {
+	err := s.rollback()
+	if dir, dirErr := Dir(); dirErr == nil && s.ID != "" {
+		os.Remove(filepath.Join(dir, s.ID+".json"))
+	}
+	return err
+}
+
+// rollback 恢复本次已写入的文件
+func (s *Session) rollback() error {
+	var errs []error
+	for _, fc := range s.Files {
+		for _, written := range s.written {
+			if written == fc.Path {
+				errs = append(errs, WriteFile(fc.Path, fc.Before, fc.Mode))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Undo 把会话中的文件恢复为修改前的内容；只要有一个文件在修复后又被改动过，就拒绝撤销且不修改任何文件
+func (s *Session) Undo() error {
+	if s.Undone != nil {
+		return fmt.Errorf("会话 %s 已于 %s 撤销", s.ID, s.Undone.Format(time.DateTime))
+	}
+	var changed []string
+	for _, fc := range s.Files {
+		current, err := os.ReadFile(fc.Path)
+		if err != nil || hash(current) != fc.AfterSHA256 {
+			changed = append(changed, fc.Path)
+		}
+	}
+	if len(changed) > 0 {
+		return fmt.Errorf("以下文件在修复后又被修改或删除，拒绝撤销:\n  %s", strings.Join(changed, "\n  "))
+	}
+
+	for _, fc := range s.Files {
+		if err := WriteFile(fc.Path, fc.Before, fc.Mode); err != nil {
+			return fmt.Errorf("恢复 %s 失败: %w", fc.Path, err)
+		}
+	}
+	now := time.Now()
+	s.Undone = &now
+	dir, err := Dir()
+	if err != nil {
+		return err
+	}
+	return s.save(dir)
+}
+
+func (s *Session) save(dir string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFile(filepath.Join(dir, s.ID+".json"), data, 0644)
+}
+
+// Dir 返回日志目录
+func Dir() (string, error) {
+	root, err := gitdiff.Root()
+	if err != nil {
+		if root, err = os.Getwd(); err != nil {
+			return "", err
+		}
+	}
+	return filepath.Join(root, DirName), nil
+}
+
+// Load 读取指定会话
+func Load(id string) (*Session, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, id+".json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("找不到修复会话 %s", id)
+		}
+		return nil, err
+	}
+	s := &Session{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("解析修复会话 %s 失败: %w", id, err)
+	}
+	return s, nil
+}
+
+// List 按时间从新到旧返回所有会话
+func List() ([]*Session, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var sessions []*Session
+	for _, e := range entries {
+		id, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok {
+			continue
+		}
+		s, err := Load(id)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].ID > sessions[j].ID })
+	return sessions, nil
+}
+
+// Latest 返回最近一次尚未撤销的会话
+func Latest() (*Session, error) {
+	sessions, err := List()
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range sessions {
+		if s.Undone == nil {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("没有可撤销的修复会话")
+}
+
+// WriteFile 原子地写入文件：先写同目录下的临时文件并设置权限，再重命名覆盖目标
+func WriteFile(path string, data []byte, mode os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // 重命名成功后为空操作
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// newID 生成按时间排序的会话编号
+func newID(dir string, t time.Time) string {
+	base := t.Format("20060102-150405")
+	id := base
+	for i := 2; ; i++ {
+		if _, err := os.Stat(filepath.Join(dir, id+".json")); os.IsNotExist(err) {
+			return id
+		}
+		id = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+func hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
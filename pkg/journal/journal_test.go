@@ -0,0 +1,216 @@
+package journal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// chdir 切换到一个不在任何 git 仓库中的临时目录，日志写在其中的 .golint-ai/journal 下
+func chdir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("GIT_CEILING_DIRECTORIES", filepath.Dir(dir))
+	t.Chdir(dir)
+	return dir
+}
+
+func writeFile(t *testing.T, path, content string, mode os.FileMode) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), mode); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// commit 暂存并写入 files（路径 -> 新内容），返回会话
+func commit(t *testing.T, when time.Time, files map[string]string) *Session {
+	t.Helper()
+	tx := Begin()
+	tx.Time = when
+	for path, content := range files {
+		if err := tx.Stage(path, []byte(content), "修复"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	return tx
+}
+
+// 任何一个文件写入失败时，已写入的文件恢复原状，日志记录被删除
+func TestCommitRollsBackOnWriteFailure(t *testing.T) {
+	dir := chdir(t)
+	a, b := filepath.Join(dir, "a.go"), filepath.Join(dir, "b.go")
+	writeFile(t, a, "a 原内容\n", 0644)
+	writeFile(t, b, "b 原内容\n", 0644)
+
+	tx := Begin()
+	if err := tx.Stage(a, []byte("a 新内容\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Stage(b, []byte("b 新内容\n")); err != nil {
+		t.Fatal(err)
+	}
+	// 暂存之后把 b 换成目录，重命名到 b 时必然失败
+	if err := os.Remove(b); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(b, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tx.Commit(); err == nil {
+		t.Fatal("写入失败时 Commit 应返回错误")
+	}
+	if got := readFile(t, a); got != "a 原内容\n" {
+		t.Fatalf("a.go 没有被回滚: %q", got)
+	}
+	if sessions, err := List(); err != nil || len(sessions) != 0 {
+		t.Fatalf("回滚后不应留下日志记录: %v, %v", sessions, err)
+	}
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if strings.Contains(e.Name(), ".tmp") {
+			t.Fatalf("留下了临时文件 %s", e.Name())
+		}
+	}
+}
+
+// 写入与撤销都保留原文件的权限
+func TestCommitPreservesMode(t *testing.T) {
+	dir := chdir(t)
+	script := filepath.Join(dir, "gen.go")
+	writeFile(t, script, "原内容\n", 0750)
+	if err := os.Chmod(script, 0750); err != nil { // 不受 umask 影响
+		t.Fatal(err)
+	}
+
+	tx := commit(t, time.Now(), map[string]string{script: "新内容\n"})
+	info, err := os.Stat(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0750 || readFile(t, script) != "新内容\n" {
+		t.Fatalf("写入后权限 = %v，内容 = %q", info.Mode().Perm(), readFile(t, script))
+	}
+
+	if err := tx.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if info, err = os.Stat(script); err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0750 || readFile(t, script) != "原内容\n" {
+		t.Fatalf("撤销后权限 = %v，内容 = %q", info.Mode().Perm(), readFile(t, script))
+	}
+}
+
+// 只要有一个文件在修复后又被改动过就拒绝撤销，且不修改任何文件
+func TestUndoRefusesChangedFiles(t *testing.T) {
+	dir := chdir(t)
+	a, b := filepath.Join(dir, "a.go"), filepath.Join(dir, "b.go")
+	writeFile(t, a, "a 原内容\n", 0644)
+	writeFile(t, b, "b 原内容\n", 0644)
+	tx := commit(t, time.Now(), map[string]string{a: "a 修复\n", b: "b 修复\n"})
+
+	writeFile(t, b, "b 手工改动\n", 0644)
+	s, err := Load(tx.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Undo(); err == nil || !strings.Contains(err.Error(), b) {
+		t.Fatalf("Undo 应拒绝并指出 b.go: %v", err)
+	}
+	if readFile(t, a) != "a 修复\n" || readFile(t, b) != "b 手工改动\n" {
+		t.Fatal("拒绝撤销时不应修改任何文件")
+	}
+
+	writeFile(t, b, "b 修复\n", 0644)
+	if err := s.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if readFile(t, a) != "a 原内容\n" || readFile(t, b) != "b 原内容\n" {
+		t.Fatal("撤销没有恢复文件")
+	}
+	if s, err = Load(tx.ID); err != nil || s.Undone == nil {
+		t.Fatalf("撤销状态没有写回日志: %v", err)
+	}
+	if err := s.Undo(); err == nil {
+		t.Fatal("已撤销的会话不能再次撤销")
+	}
+}
+
+// undo 不带参数时撤销最近一次未撤销的会话，带会话编号时撤销指定会话，--list 按时间从新到旧列出
+func TestSessionSelection(t *testing.T) {
+	dir := chdir(t)
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.Local)
+	var sessions []*Session
+	for i, when := range []time.Time{base, base.Add(time.Minute), base.Add(time.Minute)} {
+		path := filepath.Join(dir, string(rune('a'+i))+".go")
+		writeFile(t, path, "原内容\n", 0644)
+		sessions = append(sessions, commit(t, when, map[string]string{path: "修复\n"}))
+	}
+	wantIDs := []string{"20260101-120100-2", "20260101-120100", "20260101-120000"}
+	if sessions[0].ID != wantIDs[2] || sessions[1].ID != wantIDs[1] || sessions[2].ID != wantIDs[0] {
+		t.Fatalf("会话编号 = %s, %s, %s", sessions[0].ID, sessions[1].ID, sessions[2].ID)
+	}
+
+	list, err := List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, s := range list {
+		ids = append(ids, s.ID)
+	}
+	if strings.Join(ids, " ") != strings.Join(wantIDs, " ") {
+		t.Fatalf("List = %v, want %v", ids, wantIDs)
+	}
+
+	latest, err := Latest()
+	if err != nil || latest.ID != wantIDs[0] {
+		t.Fatalf("Latest = %v, %v, want %s", latest, err, wantIDs[0])
+	}
+	if err := latest.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if latest, err = Latest(); err != nil || latest.ID != wantIDs[1] {
+		t.Fatalf("撤销最近一次后 Latest = %v, %v, want %s", latest, err, wantIDs[1])
+	}
+
+	oldest, err := Load(wantIDs[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(oldest.Files) != 1 || oldest.Files[0].Path != filepath.Join(dir, "a.go") {
+		t.Fatalf("Load 读到了错误的会话: %+v", oldest.Files)
+	}
+	if err := oldest.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if readFile(t, filepath.Join(dir, "a.go")) != "原内容\n" || readFile(t, filepath.Join(dir, "b.go")) != "修复\n" {
+		t.Fatal("撤销指定会话时影响了其他会话的文件")
+	}
+
+	if _, err := Load("20000101-000000"); err == nil {
+		t.Fatal("不存在的会话应返回错误")
+	}
+	if err := latest.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Latest(); err == nil {
+		t.Fatal("全部撤销后 Latest 应返回错误")
+	}
+}
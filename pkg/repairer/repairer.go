@@ -27,47 +27,56 @@ type AIResponse struct {
 	} `json:"choices"`
 }
 
-func GetFix(varName, codeSnippet, contextErr, combinedCheckers string) (string, error) {
-	// 1. 定义缺陷字典
-	allHints := map[string]string{
-		"UnhandledError":  "【指令1：错误处理】添加标准的 'if err != nil' 逻辑，严禁忽略错误。",
-		"NilPointer":      "【指令2：空指针防护】在解引用前必须进行 nil 检查，防止运行时宕机。",
-		"ResourceLeak":    "【指令3：资源释放】使用 'defer' 显式调用 Close() 方法，防止内存或文件句柄泄露。",
-		"HardcodedSecret": "【指令4：脱敏处理】将硬编码秘钥改为从 os.Getenv() 读取，严禁源码泄露凭据。",
-		"SQLInjection":    "【指令5：参数化查询】严禁拼接 SQL 字符串，必须改用数据库驱动的占位符（? 或 $1）。",
-		"GoroutineLeak":   "【指令6：并发治理】检测到未托管的协程。请使用 sync.WaitGroup 重新包装这段代码：在 go 前面加 Add(1)，在协程内部加 defer Done()，并在函数末尾调用 Wait()。",
-	}
+// allHints 各缺陷类别的修复指导
+var allHints = map[string]string{
+	"UnhandledError":  "【指令1：错误处理】添加标准的 'if err != nil' 逻辑，严禁忽略错误。",
+	"NilPointer":      "【指令2：空指针防护】在解引用前必须进行 nil 检查，防止运行时宕机。",
+	"ResourceLeak":    "【指令3：资源释放】使用 'defer' 显式调用 Close() 方法，防止内存或文件句柄泄露。",
+	"HardcodedSecret": "【指令4：脱敏处理】将硬编码秘钥改为从 os.Getenv() 读取，严禁源码泄露凭据。",
+	"SQLInjection":    "【指令5：参数化查询】严禁拼接 SQL 字符串，必须改用数据库驱动的占位符（? 或 $1）。",
+	"GoroutineLeak":   "【指令6：并发治理】检测到未托管的协程。请使用 sync.WaitGroup 重新包装这段代码：在 go 前面加 Add(1)，在协程内部加 defer Done()，并在函数末尾调用 Wait()。",
+}
 
-	// 2. 自动识别并提取相关的修复指导（ACM 风格的关键词搜索）
-	var activeHints []string
+// activeHints 自动识别并提取相关的修复指导（ACM 风格的关键词搜索）
+func activeHints(combinedCheckers string) []string {
+	var hints []string
 	for category, hint := range allHints {
 		if strings.Contains(combinedCheckers, category) {
-			activeHints = append(activeHints, hint)
+			hints = append(hints, hint)
 		}
 	}
+	return hints
+}
+
+// feedback 注入编译器 Stderr 与复查结果反馈（自愈环）
+func feedback(contextErr string) string {
+	if contextErr == "" {
+		return ""
+	}
+	return fmt.Sprintf(
+		"\n【重要纠错】你之前的尝试未能通过校验（编译报错或缺陷仍在），请务必根据此信息修正补丁：\n%s\n",
+		contextErr)
+}
+
+func GetFix(varName, codeSnippet, contextErr, combinedCheckers string) (string, error) {
+	hints := activeHints(combinedCheckers)
 
-	// 3. 构建高度结构化的复合 Prompt
+	// 构建高度结构化的复合 Prompt
 	prompt := fmt.Sprintf(
 		"【任务】你是一个资深的 Go 语言专家。请针对以下代码片段，一并修复其中存在的【%d】个缺陷。\n"+
 			"【待修复点清单】%s\n"+
 			"【涉及核心变量】%s\n"+
 			"【专项修复要求】\n%s\n"+
 			"【原始代码片段】\n%s\n",
-		len(activeHints),
+		len(hints),
 		combinedCheckers,
 		varName,
-		strings.Join(activeHints, "\n"), // 把多个指令换行拼在一起
+		strings.Join(hints, "\n"), // 把多个指令换行拼在一起
 		codeSnippet,
 	)
+	prompt += feedback(contextErr)
 
-	// 4. 注入编译器 Stderr 反馈（自愈环）
-	if contextErr != "" {
-		prompt += fmt.Sprintf(
-			"\n【重要纠错】你之前的尝试导致了编译报错，请务必根据此信息修正补丁：\n%s\n",
-			contextErr)
-	}
-
-	// 5. 最终约束
+	// 最终约束
 	prompt += "\n【输出约束】\n" +
 		"1. 仅返回修复后的纯 Go 代码片段。\n" +
 		"2. 严禁任何文字解释，严禁包含 Markdown 标签（如 ```go）。\n" +
@@ -76,6 +85,33 @@ func GetFix(varName, codeSnippet, contextErr, combinedCheckers string) (string,
 	return callAI(prompt)
 }
 
+// GetFuncFix 把整个函数连同其中的全部缺陷一次性交给 AI，返回修复后的函数体（含首尾花括号）
+// findings 为逐条缺陷说明，AI 可以统筹修复，例如在错误检查之后再补上 defer Close()
+func GetFuncFix(funcSource string, findings []string, contextErr, combinedCheckers string) (string, error) {
+	hints := activeHints(combinedCheckers)
+
+	prompt := fmt.Sprintf(
+		"【任务】你是一个资深的 Go 语言专家。请统筹修复以下函数中的全部【%d】处缺陷，各处修复需要相互协调。\n"+
+			"【待修复点清单】%s\n"+
+			"【缺陷明细】\n- %s\n"+
+			"【专项修复要求】\n%s\n"+
+			"【原始函数】\n%s\n",
+		len(findings),
+		combinedCheckers,
+		strings.Join(findings, "\n- "),
+		strings.Join(hints, "\n"),
+		funcSource,
+	)
+	prompt += feedback(contextErr)
+
+	prompt += "\n【输出约束】\n" +
+		"1. 仅返回修复后的函数体，以 { 开始、以 } 结束，不要包含 func 关键字与函数签名。\n" +
+		"2. 严禁任何文字解释，严禁包含 Markdown 标签（如 ```go）。\n" +
+		"3. 保持函数签名与原有业务逻辑不变，可以在缺陷语句之后补充必要的语句。"
+
+	return callAI(prompt)
+}
+
 func callAI(prompt string) (string, error) {
 	cfg := config.GlobalConfig.AI
 
@@ -0,0 +1,69 @@
+package report
+
+import (
+	"encoding/xml"
+	"io"
+
+	"github.com/hsdaoqi/golint-ai/checkers"
+)
+
+type checkstyleReport struct {
+	XMLName xml.Name         `xml:"checkstyle"`
+	Version string           `xml:"version,attr"`
+	Files   []checkstyleFile `xml:"file"`
+}
+
+type checkstyleFile struct {
+	Name   string            `xml:"name,attr"`
+	Errors []checkstyleError `xml:"error"`
+}
+
+type checkstyleError struct {
+	Line     int    `xml:"line,attr"`
+	Column   int    `xml:"column,attr"`
+	Severity string `xml:"severity,attr"`
+	Message  string `xml:"message,attr"`
+	Source   string `xml:"source,attr"`
+}
+
+// checkstyleSeverity Checkstyle 只有 info/warning/error 三级
+func checkstyleSeverity(severity string) string {
+	if checkers.Severity(severity) == checkers.SeverityCritical {
+		return string(checkers.SeverityError)
+	}
+	return severity
+}
+
+// writeCheckstyle 按文件分组，每条规则输出一个 error 元素
+func writeCheckstyle(w io.Writer, records []Record) error {
+	out := checkstyleReport{Version: "5.0"}
+	index := make(map[string]int)
+	for _, r := range records {
+		i, ok := index[r.File]
+		if !ok {
+			i = len(out.Files)
+			index[r.File] = i
+			out.Files = append(out.Files, checkstyleFile{Name: r.File})
+		}
+		for j, rule := range r.Rules {
+			out.Files[i].Errors = append(out.Files[i].Errors, checkstyleError{
+				Line:     r.Start.Line,
+				Column:   r.Start.Column,
+				Severity: checkstyleSeverity(r.Severity),
+				Message:  r.message(j),
+				Source:   "golint-ai." + rule,
+			})
+		}
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
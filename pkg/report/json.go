@@ -0,0 +1,15 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+)
+
+func writeJSON(w io.Writer, records []Record) error {
+	if records == nil {
+		records = []Record{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(records)
+}
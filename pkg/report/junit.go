@@ -0,0 +1,82 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// writeJUnit 每个文件一个 testsuite，每条缺陷是一个失败的 testcase；
+// 没有缺陷时输出一个通过的用例，避免 Jenkins 把空报告当作错误
+func writeJUnit(w io.Writer, records []Record) error {
+	out := junitTestSuites{}
+	index := make(map[string]int)
+	for _, r := range records {
+		i, ok := index[r.File]
+		if !ok {
+			i = len(out.Suites)
+			index[r.File] = i
+			out.Suites = append(out.Suites, junitTestSuite{Name: r.File})
+		}
+
+		body := fmt.Sprintf("%s:%d:%d: %s", r.File, r.Start.Line, r.Start.Column, r.Message())
+		if r.Patch != "" {
+			body += fmt.Sprintf("\n\nAI 建议 (第 %d 次尝试通过编译与复查):\n%s", r.Attempts, r.Patch)
+			for _, e := range r.extraEdits() {
+				body += "\n\n同时" + describeEdit(e)
+			}
+		}
+		rules := strings.Join(r.Rules, "&")
+		out.Suites[i].Cases = append(out.Suites[i].Cases, junitTestCase{
+			Name:      fmt.Sprintf("%s:%d:%d %s", r.File, r.Start.Line, r.Start.Column, rules),
+			ClassName: "golint-ai." + rules,
+			Failure:   &junitFailure{Message: r.Message(), Type: r.Severity, Body: body},
+		})
+		out.Suites[i].Tests++
+		out.Suites[i].Failures++
+		out.Tests++
+		out.Failures++
+	}
+	if len(out.Suites) == 0 {
+		out.Tests = 1
+		out.Suites = []junitTestSuite{{Name: "golint-ai", Tests: 1, Cases: []junitTestCase{{Name: "no findings", ClassName: "golint-ai"}}}}
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
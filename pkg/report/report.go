@@ -0,0 +1,139 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// 支持的输出格式
+const (
+	FormatText       = "text"
+	FormatJSON       = "json"
+	FormatSARIF      = "sarif"
+	FormatCheckstyle = "checkstyle"
+	FormatJUnit      = "junit"
+)
+
+// Position 源码中的一个位置，行列均从 1 开始
+type Position struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Record 一条可被机器消费的缺陷记录，对应一个聚合后的缺陷
+type Record struct {
+	Rules        []string `json:"rules"`                  // 命中的规则（类别）
+	Severity     string   `json:"severity"`               // info/warning/error/critical
+	Confidence   float64  `json:"confidence"`             // 0~1
+	File         string   `json:"file"`                   // 相对当前目录的斜杠路径
+	Start        Position `json:"start"`                  // 缺陷起始位置
+	End          Position `json:"end"`                    // 缺陷结束位置
+	Messages     []string `json:"messages"`               // 与 Rules 一一对应的提示信息
+	Function     string   `json:"function,omitempty"`     // 所在函数
+	Fingerprints []string `json:"fingerprints,omitempty"` // 与 Rules 一一对应的基线指纹
+	Patch        string   `json:"patch,omitempty"`        // 修复后替换 Start~End 之间的代码（按语法结构重新排版后的 AI 补丁）
+	Edits        []Edit   `json:"edits,omitempty"`        // 应用修复所需的全部编辑，包括补丁引起的导入变化
+	Attempts     int      `json:"attempts,omitempty"`     // 请求 AI 的次数
+	Outcome      string   `json:"outcome,omitempty"`      // 补丁复查结论: fixed/unchanged/regressed
+}
+
+// Edit 把原文件中 Start~End 之间的代码替换为 Text，位置均指原文件
+type Edit struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+	Text  string   `json:"text"`
+}
+
+// Message 返回合并后的提示信息
+func (r Record) Message() string {
+	return strings.Join(r.Messages, "; ")
+}
+
+// extraEdits 返回 Patch 之外还需要的编辑，如新增或删除导入
+func (r Record) extraEdits() []Edit {
+	var extra []Edit
+	for _, e := range r.Edits {
+		if e.Start != r.Start || e.End != r.End {
+			extra = append(extra, e)
+		}
+	}
+	return extra
+}
+
+// describeEdit 以可读的形式描述一处编辑，供文本类报告展示
+func describeEdit(e Edit) string {
+	switch {
+	case e.Start == e.End:
+		return fmt.Sprintf("在第 %d 行插入:\n%s", e.Start.Line, strings.TrimSpace(e.Text))
+	case e.Start.Line == e.End.Line:
+		return fmt.Sprintf("第 %d 行改为:\n%s", e.Start.Line, strings.TrimSpace(e.Text))
+	}
+	return fmt.Sprintf("第 %d~%d 行改为:\n%s", e.Start.Line, e.End.Line, strings.TrimSpace(e.Text))
+}
+
+// message 返回第 i 条规则对应的提示信息
+func (r Record) message(i int) string {
+	if i < len(r.Messages) {
+		return r.Messages[i]
+	}
+	return r.Message()
+}
+
+// Formats 返回全部支持的格式名
+func Formats() []string {
+	return []string{FormatText, FormatJSON, FormatSARIF, FormatCheckstyle, FormatJUnit}
+}
+
+// Write 按指定格式输出记录，记录会先按文件与位置排序
+func Write(w io.Writer, format string, records []Record) error {
+	sort.SliceStable(records, func(i, j int) bool {
+		if records[i].File != records[j].File {
+			return records[i].File < records[j].File
+		}
+		if records[i].Start.Line != records[j].Start.Line {
+			return records[i].Start.Line < records[j].Start.Line
+		}
+		return records[i].Start.Column < records[j].Start.Column
+	})
+
+	switch format {
+	case FormatText, "":
+		return writeText(w, records)
+	case FormatJSON:
+		return writeJSON(w, records)
+	case FormatSARIF:
+		return writeSARIF(w, records)
+	case FormatCheckstyle:
+		return writeCheckstyle(w, records)
+	case FormatJUnit:
+		return writeJUnit(w, records)
+	}
+	return fmt.Errorf("不支持的输出格式 %q (可选: %s)", format, strings.Join(Formats(), "/"))
+}
+
+// writeText 与 go vet 相同的 file:line:col: message 格式，AI 建议缩进打印在下方
+func writeText(w io.Writer, records []Record) error {
+	for _, r := range records {
+		if _, err := fmt.Fprintf(w, "%s:%d:%d: [%s][%s] %s (置信度 %.0f%%)\n", r.File, r.Start.Line, r.Start.Column,
+			r.Severity, strings.Join(r.Rules, "&"), r.Message(), r.Confidence*100); err != nil {
+			return err
+		}
+		if r.Patch == "" {
+			continue
+		}
+		fmt.Fprintf(w, "\tAI 建议 (第 %d 次尝试通过编译与复查):\n", r.Attempts)
+		for _, line := range strings.Split(r.Patch, "\n") {
+			fmt.Fprintf(w, "\t\t%s\n", line)
+		}
+		for _, e := range r.extraEdits() {
+			desc := strings.Split(describeEdit(e), "\n")
+			fmt.Fprintf(w, "\t同时%s\n", desc[0])
+			for _, line := range desc[1:] {
+				fmt.Fprintf(w, "\t\t%s\n", line)
+			}
+		}
+	}
+	return nil
+}
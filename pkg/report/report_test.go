@@ -0,0 +1,58 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// 修复需要新增导入时，报告给出的是全部编辑，而不只是替换缺陷区间的补丁
+func fixedRecord() Record {
+	return Record{
+		Rules:    []string{"HardcodedSecret"},
+		Severity: "critical",
+		File:     "e.go",
+		Start:    Position{Line: 4, Column: 2},
+		End:      Position{Line: 4, Column: 24},
+		Messages: []string{"硬编码秘钥"},
+		Patch:    `token := os.Getenv("TOKEN")`,
+		Edits: []Edit{
+			{Start: Position{Line: 4, Column: 2}, End: Position{Line: 4, Column: 24}, Text: `token := os.Getenv("TOKEN")`},
+			{Start: Position{Line: 1, Column: 13}, End: Position{Line: 1, Column: 13}, Text: "\n\nimport \"os\""},
+		},
+		Attempts: 1,
+	}
+}
+
+func TestSARIFReplacements(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatSARIF, []Record{fixedRecord()}); err != nil {
+		t.Fatal(err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+	replacements := log.Runs[0].Results[0].Fixes[0].ArtifactChanges[0].Replacements
+	if len(replacements) != 2 {
+		t.Fatalf("replacements = %d, want 2 (补丁与导入)", len(replacements))
+	}
+	imp := replacements[1]
+	if imp.DeletedRegion.StartLine != 1 || imp.DeletedRegion.StartColumn != 13 || !strings.Contains(imp.InsertedContent.Text, `import "os"`) {
+		t.Fatalf("导入变化 = %+v", imp)
+	}
+}
+
+func TestTextShowsImportChanges(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatText, []Record{fixedRecord()}); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{`token := os.Getenv("TOKEN")`, "同时在第 1 行插入:", `import "os"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("文本报告缺少 %q:\n%s", want, out)
+		}
+	}
+}
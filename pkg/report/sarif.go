@@ -0,0 +1,192 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+
+	"github.com/hsdaoqi/golint-ai/checkers"
+)
+
+// SARIF 2.1.0 中本工具用到的子集，可直接上传到 GitHub code scanning
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	ShortDescription     sarifText          `json:"shortDescription"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifText struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID              string                 `json:"ruleId"`
+	Level               string                 `json:"level"`
+	Message             sarifText              `json:"message"`
+	Locations           []sarifLocation        `json:"locations"`
+	PartialFingerprints map[string]string      `json:"partialFingerprints,omitempty"`
+	Fixes               []sarifFix             `json:"fixes,omitempty"`
+	Properties          map[string]interface{} `json:"properties"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+	EndLine     int `json:"endLine"`
+	EndColumn   int `json:"endColumn"`
+}
+
+type sarifFix struct {
+	Description     sarifText             `json:"description"`
+	ArtifactChanges []sarifArtifactChange `json:"artifactChanges"`
+}
+
+type sarifArtifactChange struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Replacements     []sarifReplacement    `json:"replacements"`
+}
+
+type sarifReplacement struct {
+	DeletedRegion   sarifRegion `json:"deletedRegion"`
+	InsertedContent sarifText   `json:"insertedContent"`
+}
+
+// sarifLevel 将严重级别映射为 SARIF 的 level
+func sarifLevel(severity string) string {
+	switch checkers.Severity(severity) {
+	case checkers.SeverityCritical, checkers.SeverityError:
+		return "error"
+	case checkers.SeverityWarning:
+		return "warning"
+	}
+	return "note"
+}
+
+// writeSARIF 每条记录按规则拆分为独立的 result，AI 补丁写入 fixes
+func writeSARIF(w io.Writer, records []Record) error {
+	rules := make(map[string]sarifRule)
+	results := []sarifResult{}
+	for _, r := range records {
+		location := sarifArtifactLocation{URI: r.File, URIBaseID: "%SRCROOT%"}
+		region := sarifRegion{
+			StartLine: r.Start.Line, StartColumn: r.Start.Column,
+			EndLine: r.End.Line, EndColumn: r.End.Column,
+		}
+
+		for i, rule := range r.Rules {
+			if _, ok := rules[rule]; !ok {
+				rules[rule] = newSARIFRule(rule)
+			}
+			res := sarifResult{
+				RuleID:     rule,
+				Level:      sarifLevel(r.Severity),
+				Message:    sarifText{Text: r.message(i)},
+				Locations:  []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: location, Region: region}}},
+				Properties: map[string]interface{}{"severity": r.Severity, "confidence": r.Confidence},
+			}
+			if r.Attempts > 0 {
+				res.Properties["attempts"] = r.Attempts
+			}
+			if r.Outcome != "" {
+				res.Properties["outcome"] = r.Outcome
+			}
+			if i < len(r.Fingerprints) {
+				res.PartialFingerprints = map[string]string{"golintAi/v1": r.Fingerprints[i]}
+			}
+			if replacements := sarifReplacements(r, region); len(replacements) > 0 {
+				res.Fixes = []sarifFix{{
+					Description: sarifText{Text: "AI 修复建议"},
+					ArtifactChanges: []sarifArtifactChange{{
+						ArtifactLocation: location,
+						Replacements:     replacements,
+					}},
+				}}
+			}
+			results = append(results, res)
+		}
+	}
+
+	driver := sarifDriver{Name: "golint-ai", InformationURI: "https://github.com/hsdaoqi/golint-ai", Rules: []sarifRule{}}
+	for _, rule := range rules {
+		driver.Rules = append(driver.Rules, rule)
+	}
+	sort.Slice(driver.Rules, func(i, j int) bool { return driver.Rules[i].ID < driver.Rules[j].ID })
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	})
+}
+
+// sarifReplacements 把修复的全部编辑（含导入变化）转换为 replacements，没有编辑时退回到只替换缺陷区间的 Patch
+func sarifReplacements(r Record, region sarifRegion) []sarifReplacement {
+	if len(r.Edits) == 0 {
+		if r.Patch == "" {
+			return nil
+		}
+		return []sarifReplacement{{DeletedRegion: region, InsertedContent: sarifText{Text: r.Patch}}}
+	}
+	var replacements []sarifReplacement
+	for _, e := range r.Edits {
+		replacements = append(replacements, sarifReplacement{
+			DeletedRegion: sarifRegion{
+				StartLine: e.Start.Line, StartColumn: e.Start.Column,
+				EndLine: e.End.Line, EndColumn: e.End.Column,
+			},
+			InsertedContent: sarifText{Text: e.Text},
+		})
+	}
+	return replacements
+}
+
+func newSARIFRule(id string) sarifRule {
+	rule := sarifRule{ID: id, ShortDescription: sarifText{Text: id}, DefaultConfiguration: sarifConfiguration{Level: "note"}}
+	if c, ok := checkers.Lookup(id); ok {
+		rule.ShortDescription.Text = checkers.NewAnalyzer(c).Doc
+		rule.DefaultConfiguration.Level = sarifLevel(string(c.Severity()))
+	}
+	return rule
+}
@@ -0,0 +1,101 @@
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/hsdaoqi/golint-ai/pkg/analyzer"
+	"github.com/hsdaoqi/golint-ai/pkg/gitdiff"
+	"github.com/hsdaoqi/golint-ai/pkg/udiff"
+	"github.com/hsdaoqi/golint-ai/pkg/verifier"
+)
+
+// Diff 收集所有补丁并生成 unified diff，不修改任何文件；修复范围与 fix 相同（需在 analyzer.FixMode 下运行）
+// toStdout 为 true 时打印到标准输出，patchOut 非空时写成可供 git apply 使用的补丁文件
+func Diff(patterns []string, toStdout bool, patchOut string) int {
+	defer verifier.Cleanup()
+	graph, err := analyze(patterns)
+	if err != nil {
+		log.Print(err)
+		return ExitError
+	}
+
+	exitCode := ExitOK
+	var fset *token.FileSet
+	var findings []analyzer.FixResult
+	byFile := make(map[string][]analyzer.FixResult)
+	for _, act := range graph.Roots {
+		if act.Err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", act.Package.PkgPath, act.Err)
+			exitCode = ExitError
+			continue
+		}
+		res, ok := act.Result.(*analyzer.Result)
+		if !ok {
+			continue
+		}
+		fset = act.Package.Fset // 同一次加载的所有包共享一个 FileSet
+		findings = append(findings, res.Findings...)
+	}
+	for _, f := range analyzer.PreviewFixes(fset, findings) {
+		byFile[f.Agg.Filename] = append(byFile[f.Agg.Filename], f)
+	}
+
+	files := make([]string, 0, len(byFile))
+	for name := range byFile {
+		files = append(files, name)
+	}
+	sort.Strings(files)
+
+	// 每条修复的去向在应用到所在文件之后才记入汇总，文件中的补丁无法应用时记为失败
+	var patch bytes.Buffer
+	patched := 0
+	for _, name := range files {
+		content, newContent, err := analyzer.PreviewFile(fset, name, byFile[name])
+		if err != nil {
+			log.Printf("%s: %v", name, err)
+			exitCode = ExitError
+			continue
+		}
+		if newContent == nil {
+			continue
+		}
+		patch.WriteString(udiff.Unified(patchPath(name), content, newContent))
+		patched++
+	}
+
+	// 汇总写到标准错误，标准输出只有补丁本身
+	analyzer.Summary.Print(os.Stderr)
+	if toStdout {
+		os.Stdout.Write(patch.Bytes())
+	}
+	if patchOut != "" {
+		if err := os.WriteFile(patchOut, patch.Bytes(), 0644); err != nil {
+			log.Printf("写入补丁文件失败: %v", err)
+			return ExitError
+		}
+		fmt.Fprintf(os.Stderr, "已将 %d 个文件的修复写入 %s，可用 git apply 应用\n", patched, patchOut)
+	}
+	return exitCode
+}
+
+// patchPath 返回相对 git 仓库根目录的路径，不在仓库中时相对当前目录
+func patchPath(filename string) string {
+	root, err := gitdiff.Root()
+	if err != nil {
+		return relPath(filename)
+	}
+	if resolved, err := filepath.EvalSymlinks(filename); err == nil {
+		filename = resolved
+	}
+	rel, err := filepath.Rel(root, filename)
+	if err != nil {
+		return relPath(filename)
+	}
+	return filepath.ToSlash(rel)
+}
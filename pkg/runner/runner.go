@@ -0,0 +1,275 @@
+package runner
+
+import (
+	"fmt"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hsdaoqi/golint-ai/checkers"
+	"github.com/hsdaoqi/golint-ai/pkg/analyzer"
+	"github.com/hsdaoqi/golint-ai/pkg/baseline"
+	"github.com/hsdaoqi/golint-ai/pkg/config"
+	"github.com/hsdaoqi/golint-ai/pkg/report"
+	"github.com/hsdaoqi/golint-ai/pkg/verifier"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/checker"
+	"golang.org/x/tools/go/packages"
+)
+
+// 进程退出码，与 go vet 的约定保持一致
+const (
+	ExitOK       = 0 // 没有需要阻断的缺陷
+	ExitError    = 1 // 加载或分析过程出错
+	ExitFindings = 3 // 存在达到 FailOn 级别的缺陷
+)
+
+// FailOn 达到该级别的缺陷会让进程以 ExitFindings 退出，为空表示从不因缺陷失败
+var FailOn = checkers.SeverityInfo
+
+// Format / Output 控制 scan 报告的格式与输出文件，Output 为空时写到标准输出
+var (
+	Format = report.FormatText
+	Output string
+)
+
+// Run 加载 patterns 指定的包并执行 analyzer.Analyzer，返回进程退出码
+func Run(patterns []string) int {
+	defer verifier.Cleanup()
+	graph, err := analyze(patterns)
+	if err != nil {
+		log.Print(err)
+		return ExitError
+	}
+
+	exitCode := ExitOK
+	var records []report.Record
+	var fset *token.FileSet
+	var findings []analyzer.FixResult
+	for _, act := range graph.Roots {
+		if act.Err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", act.Package.PkgPath, act.Err)
+			exitCode = ExitError
+			continue
+		}
+		records = append(records, collectRecords(act)...)
+
+		res, ok := act.Result.(*analyzer.Result)
+		if !ok {
+			continue
+		}
+		fset = act.Package.Fset // 同一次加载的所有包共享一个 FileSet
+		findings = append(findings, res.Findings...)
+		if FailOn == "" || exitCode != ExitOK {
+			continue
+		}
+		for _, f := range res.Findings {
+			if f.Agg.Severity.AtLeast(FailOn) {
+				exitCode = ExitFindings
+				break
+			}
+		}
+	}
+
+	if analyzer.FixMode {
+		// 所有包分析完成后再统一交互，修复已在会话中逐条展示，最后只打印汇总
+		if len(findings) > 0 {
+			if err := analyzer.NewFixSession(fset, findings, os.Stdin, os.Stdout).Run(); err != nil {
+				exitCode = ExitError
+			}
+		}
+		analyzer.Summary.Print(os.Stdout)
+		return exitCode
+	}
+	if err := writeReport(records); err != nil {
+		log.Printf("输出报告失败: %v", err)
+		return ExitError
+	}
+	return exitCode
+}
+
+// collectRecords 将一个包的分析结果与失效屏蔽指令转换为报告记录
+func collectRecords(act *checker.Action) []report.Record {
+	fset := act.Package.Fset
+	var records []report.Record
+	if res, ok := act.Result.(*analyzer.Result); ok {
+		for _, f := range res.Findings {
+			start, end := fset.Position(f.Agg.Pos), fset.Position(f.Agg.End)
+			records = append(records, report.Record{
+				Rules:        f.Agg.Categories,
+				Severity:     string(f.Agg.Severity),
+				Confidence:   f.Agg.Confidence,
+				File:         relPath(start.Filename),
+				Start:        report.Position{Line: start.Line, Column: start.Column},
+				End:          report.Position{Line: end.Line, Column: end.Column},
+				Messages:     f.Agg.Messages,
+				Function:     f.Agg.Function,
+				Fingerprints: f.Agg.Fingerprints,
+				Patch:        patchText(f),
+				Edits:        reportEdits(fset, f),
+				Attempts:     f.Attempts,
+				Outcome:      f.Outcome,
+			})
+		}
+	}
+	for _, diag := range act.Diagnostics {
+		if diag.Category != checkers.StaleSuppression {
+			continue
+		}
+		posn := fset.Position(diag.Pos)
+		records = append(records, report.Record{
+			Rules:      []string{checkers.StaleSuppression},
+			Severity:   string(checkers.SeverityInfo),
+			Confidence: 1,
+			File:       relPath(posn.Filename),
+			Start:      report.Position{Line: posn.Line, Column: posn.Column},
+			End:        report.Position{Line: posn.Line, Column: posn.Column},
+			Messages:   []string{diag.Message},
+		})
+	}
+	return records
+}
+
+// patchText 返回修复后替换缺陷区间的代码，即重新排版后实际写入文件的补丁，而不是 AI 的原始回复
+func patchText(f analyzer.FixResult) string {
+	if len(f.Edits) == 0 {
+		return ""
+	}
+	return string(f.Edits[0].NewText)
+}
+
+// reportEdits 把修复的全部编辑（含导入变化）转换为报告中的位置与文本
+func reportEdits(fset *token.FileSet, f analyzer.FixResult) []report.Edit {
+	edits, err := f.SuggestedEdits(fset)
+	if err != nil {
+		log.Printf("无法计算补丁的导入变化 [%s]: %v", f.Agg.VarName, err)
+		edits = f.Edits
+	}
+	var result []report.Edit
+	for _, e := range edits {
+		start, end := fset.Position(e.Pos), fset.Position(e.End)
+		result = append(result, report.Edit{
+			Start: report.Position{Line: start.Line, Column: start.Column},
+			End:   report.Position{Line: end.Line, Column: end.Column},
+			Text:  string(e.NewText),
+		})
+	}
+	return result
+}
+
+// writeReport 按 Format 输出报告到 Output 或标准输出
+func writeReport(records []report.Record) error {
+	if Output == "" {
+		return report.Write(os.Stdout, Format, records)
+	}
+	f, err := os.Create(Output)
+	if err != nil {
+		return err
+	}
+	if err := report.Write(f, Format, records); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// relPath 返回相对当前目录的斜杠路径，不在当前目录下时保持原样
+func relPath(filename string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return filename
+	}
+	rel, err := filepath.Rel(wd, filename)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return filename
+	}
+	return filepath.ToSlash(rel)
+}
+
+// WriteBaseline 分析 patterns 指定的包，把当前所有缺陷记录为基线文件
+func WriteBaseline(patterns []string, path string) error {
+	graph, err := analyze(patterns)
+	if err != nil {
+		return err
+	}
+
+	b := &baseline.Baseline{}
+	for _, act := range graph.Roots {
+		if act.Err != nil {
+			return fmt.Errorf("%s: %w", act.Package.PkgPath, act.Err)
+		}
+		res, ok := act.Result.(*analyzer.Result)
+		if !ok {
+			continue
+		}
+		for _, f := range res.Findings {
+			file := relPath(f.Agg.Filename)
+			for i, fp := range f.Agg.Fingerprints {
+				b.Add(baseline.Entry{
+					Fingerprint: fp,
+					Category:    f.Agg.Categories[i],
+					Function:    f.Agg.Function,
+					File:        file,
+					Message:     f.Agg.Messages[i],
+				})
+			}
+		}
+	}
+
+	if err := b.Write(path); err != nil {
+		return err
+	}
+	fmt.Printf("已将 %d 条缺陷记录到基线 %s\n", len(b.Entries), path)
+	return nil
+}
+
+// analyze 加载包并运行 analyzer.Analyzer
+func analyze(patterns []string) (*checker.Graph, error) {
+	pkgs, err := load(patterns)
+	if err != nil {
+		return nil, fmt.Errorf("加载包失败: %w", err)
+	}
+	graph, err := checker.Analyze([]*analysis.Analyzer{analyzer.Analyzer}, pkgs, nil)
+	if err != nil {
+		return nil, fmt.Errorf("分析失败: %w", err)
+	}
+	return graph, nil
+}
+
+// load 以源码方式加载包（除非配置了 ignore_tests，否则包含测试文件），任何包存在错误时返回失败
+func load(patterns []string) ([]*packages.Package, error) {
+	cfg := &packages.Config{Mode: packages.LoadAllSyntax | packages.NeedForTest, Tests: !config.Load().Analysis.IgnoreTests}
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, err
+	}
+	if n := packages.PrintErrors(pkgs); n > 0 {
+		return nil, fmt.Errorf("%d 个包存在错误", n)
+	}
+	return dedupTestVariants(pkgs), nil
+}
+
+// dedupTestVariants 开启 Tests 后同一批源文件会同时出现在 p 与 "p [p.test]" 中，
+// 这里只保留包含测试文件的那个变体，避免重复汇报和重复请求 AI
+func dedupTestVariants(pkgs []*packages.Package) []*packages.Package {
+	hasTestVariant := make(map[string]bool)
+	for _, p := range pkgs {
+		if p.ForTest != "" && p.PkgPath == p.ForTest {
+			hasTestVariant[p.PkgPath] = true
+		}
+	}
+
+	var res []*packages.Package
+	for _, p := range pkgs {
+		if strings.HasSuffix(p.PkgPath, ".test") {
+			continue // go test 生成的 main 包
+		}
+		if p.ForTest == "" && hasTestVariant[p.PkgPath] {
+			continue
+		}
+		res = append(res, p)
+	}
+	return res
+}
@@ -0,0 +1,138 @@
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"go/token"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/hsdaoqi/golint-ai/pkg/analyzer"
+	"github.com/hsdaoqi/golint-ai/pkg/journal"
+	"github.com/hsdaoqi/golint-ai/pkg/udiff"
+	"github.com/hsdaoqi/golint-ai/pkg/verifier"
+	"golang.org/x/tools/go/analysis"
+)
+
+// ApplySuggested 是 golint-ai-fix 的驱动：打印每条诊断，并按诊断附带的 SuggestedFix 预览或应用修复。
+// -diff / -fix 的含义与 singlechecker 相同；singlechecker.Main 直接调用 os.Exit，校验沙箱来不及清理，这里只返回退出码。
+// diff 为 true 时把修复以 unified diff 打印到标准输出；fix 为 true 时以事务写回文件并记录修复日志
+func ApplySuggested(patterns []string, diff, fix bool) int {
+	defer verifier.Cleanup()
+	graph, err := analyze(patterns)
+	if err != nil {
+		log.Print(err)
+		return ExitError
+	}
+
+	exitCode := ExitOK
+	var fset *token.FileSet
+	byFile := make(map[string][]analysis.Diagnostic)
+	for _, act := range graph.Roots {
+		if act.Err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", act.Package.PkgPath, act.Err)
+			exitCode = ExitError
+			continue
+		}
+		fset = act.Package.Fset // 同一次加载的所有包共享一个 FileSet
+		for _, d := range act.Diagnostics {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", fset.Position(d.Pos), d.Message)
+			if (len(d.SuggestedFixes) == 0 || !fix) && exitCode == ExitOK {
+				exitCode = ExitFindings
+			}
+			if len(d.SuggestedFixes) > 0 && (diff || fix) {
+				name := fset.File(d.Pos).Name()
+				byFile[name] = append(byFile[name], d)
+			}
+		}
+	}
+
+	files := make([]string, 0, len(byFile))
+	for name := range byFile {
+		files = append(files, name)
+	}
+	sort.Strings(files)
+
+	tx := journal.Begin()
+	for _, name := range files {
+		content, err := os.ReadFile(name)
+		if err != nil {
+			log.Printf("无法读取文件: %v", err)
+			exitCode = ExitError
+			continue
+		}
+		edits, messages, conflicts := selectFixes(fset, byFile[name])
+		if conflicts > 0 {
+			log.Printf("%s 中有 %d 个修复与其他修复重叠，未应用，再运行一次即可应用", name, conflicts)
+			if exitCode == ExitOK {
+				exitCode = ExitFindings
+			}
+		}
+		newContent, err := analyzer.FixFile(fset, name, content, edits)
+		if err != nil {
+			log.Printf("%s 中的修复无法应用，已跳过: %v", name, err)
+			exitCode = ExitError
+			continue
+		}
+		if diff {
+			os.Stdout.WriteString(udiff.Unified(patchPath(name), content, newContent))
+		}
+		if fix {
+			if err := tx.Stage(name, newContent, messages...); err != nil {
+				log.Printf("无法暂存 %s: %v", name, err)
+				exitCode = ExitError
+			}
+		}
+	}
+	if fix && len(tx.Files) > 0 {
+		if err := tx.Commit(); err != nil {
+			log.Print(err)
+			return ExitError
+		}
+		fmt.Fprintf(os.Stderr, "已修复 %d 个文件，可用 golint-ai undo %s 撤销\n", len(tx.Files), tx.ID)
+	}
+	return exitCode
+}
+
+// selectFixes 按诊断位置的顺序选出同一文件中互不重叠的修复，返回这些修复的全部编辑与说明，以及因重叠被放弃的修复数。
+// 不同修复中完全相同的编辑（如补上同一个导入）只保留一份；一个修复只要有一处编辑与已选的编辑冲突就整体放弃
+func selectFixes(fset *token.FileSet, diags []analysis.Diagnostic) ([]analysis.TextEdit, []string, int) {
+	sorted := append([]analysis.Diagnostic(nil), diags...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Pos < sorted[j].Pos })
+
+	var edits []analysis.TextEdit
+	var messages []string
+	conflicts := 0
+	for _, d := range sorted {
+		for _, fix := range d.SuggestedFixes {
+			added, ok := addEdits(edits, fix.TextEdits)
+			if !ok {
+				conflicts++
+				continue
+			}
+			edits = append(edits, added...)
+			messages = append(messages, fmt.Sprintf("第 %d 行 %s", fset.Position(d.Pos).Line, fix.Message))
+		}
+	}
+	return edits, messages, conflicts
+}
+
+// addEdits 返回 candidate 中尚未出现在 edits 里的编辑；任何一处与已有编辑重叠（或在同一位置插入不同内容）时返回 false
+func addEdits(edits, candidate []analysis.TextEdit) ([]analysis.TextEdit, bool) {
+	var added []analysis.TextEdit
+next:
+	for _, e := range candidate {
+		for _, prev := range edits {
+			switch {
+			case prev.Pos == e.Pos && prev.End == e.End && bytes.Equal(prev.NewText, e.NewText):
+				continue next
+			case e.Pos < prev.End && prev.Pos < e.End,
+				e.Pos == prev.Pos && (e.Pos == e.End || prev.Pos == prev.End):
+				return nil, false
+			}
+		}
+		added = append(added, e)
+	}
+	return added, true
+}
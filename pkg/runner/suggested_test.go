@@ -0,0 +1,64 @@
+package runner
+
+import (
+	"go/token"
+	"reflect"
+	"testing"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+func TestSelectFixes(t *testing.T) {
+	fset := token.NewFileSet()
+	tf := fset.AddFile("a.go", -1, 200)
+	edit := func(start, end int, text string) analysis.TextEdit {
+		return analysis.TextEdit{Pos: tf.Pos(start), End: tf.Pos(end), NewText: []byte(text)}
+	}
+	diag := func(pos int, edits ...analysis.TextEdit) analysis.Diagnostic {
+		return analysis.Diagnostic{Pos: tf.Pos(pos), SuggestedFixes: []analysis.SuggestedFix{{Message: "fix", TextEdits: edits}}}
+	}
+	imports := edit(13, 13, "\n\nimport \"os\"")
+	tests := []struct {
+		name      string
+		diags     []analysis.Diagnostic
+		want      []analysis.TextEdit
+		conflicts int
+	}{
+		{
+			name:  "相同的导入编辑只保留一份",
+			diags: []analysis.Diagnostic{diag(50, edit(50, 60, "a"), imports), diag(80, edit(80, 90, "b"), imports)},
+			want:  []analysis.TextEdit{edit(50, 60, "a"), imports, edit(80, 90, "b")},
+		},
+		{
+			name: "导入编辑冲突时放弃后一个修复",
+			diags: []analysis.Diagnostic{
+				diag(80, edit(80, 90, "b"), edit(13, 13, "\n\nimport \"sync\"")),
+				diag(50, edit(50, 60, "a"), imports),
+			},
+			want:      []analysis.TextEdit{edit(50, 60, "a"), imports},
+			conflicts: 1,
+		},
+		{
+			name:      "区间重叠",
+			diags:     []analysis.Diagnostic{diag(50, edit(50, 60, "a")), diag(55, edit(55, 70, "b"))},
+			want:      []analysis.TextEdit{edit(50, 60, "a")},
+			conflicts: 1,
+		},
+		{
+			name:  "首尾相接",
+			diags: []analysis.Diagnostic{diag(50, edit(50, 60, "a")), diag(60, edit(60, 70, "b"))},
+			want:  []analysis.TextEdit{edit(50, 60, "a"), edit(60, 70, "b")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			edits, messages, conflicts := selectFixes(fset, tt.diags)
+			if !reflect.DeepEqual(edits, tt.want) || conflicts != tt.conflicts {
+				t.Fatalf("selectFixes = %v, %d 个冲突; want %v, %d 个冲突", edits, conflicts, tt.want, tt.conflicts)
+			}
+			if len(messages) != len(tt.diags)-tt.conflicts {
+				t.Fatalf("修复说明 = %v", messages)
+			}
+		})
+	}
+}
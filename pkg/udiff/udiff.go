@@ -0,0 +1,205 @@
+package udiff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// contextLines 每个 hunk 前后保留的上下文行数，与 git diff 默认值一致
+const contextLines = 3
+
+type opKind byte
+
+const (
+	opEqual  opKind = ' '
+	opDelete opKind = '-'
+	opInsert opKind = '+'
+)
+
+type op struct {
+	kind opKind
+	line string
+}
+
+// Unified 生成 git apply 可以直接使用的 unified diff，name 为相对仓库根目录的斜杠路径；
+// 内容相同时返回空串
+func Unified(name string, oldContent, newContent []byte) string {
+	if string(oldContent) == string(newContent) {
+		return ""
+	}
+	a, b := splitLines(string(oldContent)), splitLines(string(newContent))
+	ops := diffLines(a, b)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "diff --git a/%s b/%s\n", name, name)
+	fmt.Fprintf(&sb, "--- a/%s\n+++ b/%s\n", name, name)
+	for _, h := range hunks(ops) {
+		writeHunk(&sb, ops, h)
+	}
+	return sb.String()
+}
+
+// splitLines 按行切分并保留换行符，便于识别文件末尾缺少换行的情况
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines Myers 差分算法，返回把 a 变为 b 的最短编辑脚本
+func diffLines(a, b []string) []op {
+	n, m := len(a), len(b)
+	max := n + m
+	offset := max + 1
+	v := make([]int, 2*max+2)
+	var trace [][]int
+
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(a, b, trace, d, offset)
+			}
+		}
+	}
+	return nil
+}
+
+func backtrack(a, b []string, trace [][]int, d, offset int) []op {
+	x, y := len(a), len(b)
+	var ops []op
+	for ; d > 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			ops = append(ops, op{opEqual, a[x]})
+		}
+		if x == prevX {
+			y--
+			ops = append(ops, op{opInsert, b[y]})
+		} else {
+			x--
+			ops = append(ops, op{opDelete, a[x]})
+		}
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		ops = append(ops, op{opEqual, a[x]})
+	}
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+// hunk 编辑脚本中的一段 [start, end)
+type hunk struct {
+	start, end int
+}
+
+// hunks 把相距不超过 2*contextLines 的改动合并为同一个 hunk
+func hunks(ops []op) []hunk {
+	var res []hunk
+	for i := 0; i < len(ops); i++ {
+		if ops[i].kind == opEqual {
+			continue
+		}
+		start := i - contextLines
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for j := i; j < len(ops); j++ {
+			if ops[j].kind != opEqual {
+				end = j + 1
+				continue
+			}
+			if j-end >= 2*contextLines {
+				break
+			}
+		}
+		end += contextLines
+		if end > len(ops) {
+			end = len(ops)
+		}
+		if n := len(res); n > 0 && start <= res[n-1].end {
+			res[n-1].end = end
+		} else {
+			res = append(res, hunk{start, end})
+		}
+		i = end - 1
+	}
+	return res
+}
+
+func writeHunk(sb *strings.Builder, ops []op, h hunk) {
+	oldStart, newStart := 1, 1
+	for _, o := range ops[:h.start] {
+		if o.kind != opInsert {
+			oldStart++
+		}
+		if o.kind != opDelete {
+			newStart++
+		}
+	}
+	var oldLen, newLen int
+	for _, o := range ops[h.start:h.end] {
+		if o.kind != opInsert {
+			oldLen++
+		}
+		if o.kind != opDelete {
+			newLen++
+		}
+	}
+	// 按 unified diff 约定，长度为 0 的一侧起始行号指向其前一行
+	if oldLen == 0 {
+		oldStart--
+	}
+	if newLen == 0 {
+		newStart--
+	}
+
+	fmt.Fprintf(sb, "@@ -%s +%s @@\n", hunkRange(oldStart, oldLen), hunkRange(newStart, newLen))
+	for _, o := range ops[h.start:h.end] {
+		sb.WriteByte(byte(o.kind))
+		sb.WriteString(o.line)
+		if !strings.HasSuffix(o.line, "\n") {
+			sb.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+func hunkRange(start, length int) string {
+	if length == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, length)
+}
@@ -0,0 +1,79 @@
+package udiff
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// numbered 返回 1..n 每行一个数字的内容
+func numbered(n int) string {
+	var sb strings.Builder
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&sb, "%d\n", i)
+	}
+	return sb.String()
+}
+
+// 生成的补丁必须能被 git apply 原样应用，得到新内容
+func TestUnifiedGitApply(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git 不可用")
+	}
+	long := numbered(30)
+	tests := []struct {
+		name     string
+		old, new string
+	}{
+		{"修改一行", "a\nb\nc\n", "a\nB\nc\n"},
+		{"开头插入", "a\nb\n", "x\na\nb\n"},
+		{"末尾删除", "a\nb\nc\n", "a\nb\n"},
+		{"原文件末尾没有换行", "a\nb", "a\nc\n"},
+		{"新文件末尾没有换行", "a\nb\n", "a\nc"},
+		{"相距较远的两处改动", long, strings.Replace(strings.Replace(long, "3\n", "three\n", 1), "27\n", "", 1)},
+		{"相距较近的改动合并为一个 hunk", long, strings.Replace(strings.Replace(long, "10\n", "ten\n", 1), "14\n", "fourteen\n", 1)},
+		{"全部替换", "a\nb\n", "c\nd\ne\n"},
+		{"清空", "a\nb\n", ""},
+		{"多字节字符", "// 注释\nx := 1\n", "// 新注释\nx := 2\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			name := "pkg/f.go"
+			path := filepath.Join(dir, filepath.FromSlash(name))
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(tt.old), 0644); err != nil {
+				t.Fatal(err)
+			}
+			patch := Unified(name, []byte(tt.old), []byte(tt.new))
+			patchFile := filepath.Join(t.TempDir(), "fix.patch")
+			if err := os.WriteFile(patchFile, []byte(patch), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			cmd := exec.Command("git", "apply", patchFile)
+			cmd.Dir = dir
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("git apply 失败: %v\n%s\n补丁:\n%s", err, out, patch)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.new {
+				t.Fatalf("应用补丁后得到 %q, want %q\n补丁:\n%s", got, tt.new, patch)
+			}
+		})
+	}
+}
+
+func TestUnifiedNoChange(t *testing.T) {
+	if patch := Unified("f.go", []byte("a\n"), []byte("a\n")); patch != "" {
+		t.Fatalf("内容相同时应返回空串，得到:\n%s", patch)
+	}
+}
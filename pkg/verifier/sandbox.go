@@ -0,0 +1,287 @@
+package verifier
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hsdaoqi/golint-ai/pkg/config"
+)
+
+// sandbox 是校验 AI 代码时运行 go 命令的受限环境，整个进程共享一个临时根目录：
+//   - 环境变量被清空，只保留必要的 Go 变量；GOFLAGS 被覆盖，无法注入 -toolexec 等参数
+//   - GOPROXY=off 离线运行，GOCACHE、HOME、TMPDIR 都指向临时目录
+//   - go.mod 通过 -modfile 使用临时副本，-mod=mod 也不会改写原文件
+//   - 默认 CGO_ENABLED=0；校验只会 build/list/test，从不执行 go generate
+//   - 每个 go 进程都受 CPU 时间与虚拟内存限制（仅 unix），并有整体的超时
+//
+// 这些限制只约束 go 命令本身，并不是安全隔离：go test 运行的测试二进制以当前用户身份执行，
+// 仍然可以读写用户有权限的任何文件（包括 modCache）并访问网络
+type sandbox struct {
+	root     string // 临时根目录，go 命令的缓存、临时文件与模块副本都在这里
+	goCmd    string // 带资源限制的 go 命令
+	modCache string // 只读使用调用方的模块缓存，离线解析依赖
+
+	mu      sync.Mutex
+	modules map[string]*module // 目录 -> 所属模块
+}
+
+// module 沙箱中的一个模块
+type module struct {
+	root    string // 模块根目录，GOPATH 模式下为空
+	modfile string // go.mod 在沙箱中的副本
+	copyDir string // 供 go test 使用的模块副本，首次需要时创建
+}
+
+var (
+	sandboxOnce sync.Once
+	theSandbox  *sandbox
+	sandboxErr  error
+)
+
+// getSandbox 返回进程内共享的沙箱，首次调用时创建
+func getSandbox() (*sandbox, error) {
+	sandboxOnce.Do(func() {
+		theSandbox, sandboxErr = newSandbox()
+	})
+	return theSandbox, sandboxErr
+}
+
+// Cleanup 删除沙箱的临时目录，进程退出前调用
+func Cleanup() {
+	if theSandbox != nil {
+		os.RemoveAll(theSandbox.root)
+	}
+}
+
+func newSandbox() (*sandbox, error) {
+	realGo, err := exec.LookPath("go")
+	if err != nil {
+		return nil, fmt.Errorf("找不到 go 命令: %w", err)
+	}
+	modCache, err := exec.Command(realGo, "env", "GOMODCACHE").Output()
+	if err != nil {
+		return nil, fmt.Errorf("无法获取 GOMODCACHE: %w", err)
+	}
+
+	root, err := os.MkdirTemp("", "golint_sandbox_*")
+	if err != nil {
+		return nil, fmt.Errorf("创建沙箱目录失败: %w", err)
+	}
+	for _, dir := range []string{"bin", "cache", "home", "tmp", "gopath", "mod", "src"} {
+		if err := os.Mkdir(filepath.Join(root, dir), 0755); err != nil {
+			os.RemoveAll(root)
+			return nil, err
+		}
+	}
+	goCmd, err := limitedGo(filepath.Join(root, "bin"), realGo)
+	if err != nil {
+		os.RemoveAll(root)
+		return nil, fmt.Errorf("创建 go 包装脚本失败: %w", err)
+	}
+	return &sandbox{
+		root:     root,
+		goCmd:    goCmd,
+		modCache: strings.TrimSpace(string(modCache)),
+		modules:  make(map[string]*module),
+	}, nil
+}
+
+// module 返回 dir 所属的模块，首次访问时把 go.mod 与 go.sum 复制到沙箱中
+func (s *sandbox) module(dir string) (*module, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if m, ok := s.modules[dir]; ok {
+		return m, nil
+	}
+
+	cmd := exec.Command(s.goCmd, "env", "GOMOD")
+	cmd.Dir, cmd.Env = dir, s.baseEnv(false)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("无法确定 %s 所属的模块: %w", dir, err)
+	}
+	m := &module{}
+	if gomod := strings.TrimSpace(string(out)); gomod != "" && gomod != os.DevNull {
+		m.root = filepath.Dir(gomod)
+		for _, existing := range s.modules {
+			if existing.root == m.root {
+				m = existing
+			}
+		}
+		if m.modfile == "" {
+			copyDir, err := os.MkdirTemp(filepath.Join(s.root, "mod"), "m*")
+			if err != nil {
+				return nil, err
+			}
+			m.modfile = filepath.Join(copyDir, "go.mod")
+			if err := copyFile(gomod, m.modfile); err != nil {
+				return nil, err
+			}
+			sum := filepath.Join(m.root, "go.sum")
+			if _, err := os.Stat(sum); err == nil {
+				if err := copyFile(sum, filepath.Join(copyDir, "go.sum")); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	s.modules[dir] = m
+	return m, nil
+}
+
+// testCopy 返回 dir 在模块副本中对应的目录，go test 在副本中运行，测试写入的相对路径文件不会落到源码树中
+func (s *sandbox) testCopy(dir string) (string, *module, error) {
+	m, err := s.module(dir)
+	if err != nil {
+		return "", nil, err
+	}
+	if m.root == "" {
+		return dir, m, nil // GOPATH 模式下无法确定复制范围，只能原地运行
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if m.copyDir == "" {
+		copyDir, err := os.MkdirTemp(filepath.Join(s.root, "src"), "m*")
+		if err != nil {
+			return "", nil, err
+		}
+		if err := copyTree(m.root, copyDir); err != nil {
+			return "", nil, fmt.Errorf("复制模块失败: %w", err)
+		}
+		m.copyDir = copyDir
+	}
+	rel, err := filepath.Rel(m.root, dir)
+	if err != nil {
+		return "", nil, err
+	}
+	return filepath.Join(m.copyDir, rel), m, nil
+}
+
+// baseEnv 返回清空后的基础环境变量
+func (s *sandbox) baseEnv(race bool) []string {
+	cfg := config.Load().Verify
+	env := []string{
+		"PATH=" + filepath.Join(s.root, "bin") + string(os.PathListSeparator) + os.Getenv("PATH"),
+		"HOME=" + filepath.Join(s.root, "home"),
+		"TMPDIR=" + filepath.Join(s.root, "tmp"),
+		"GOTMPDIR=" + filepath.Join(s.root, "tmp"),
+		"GOCACHE=" + s.goCache(),
+		"GOPATH=" + filepath.Join(s.root, "gopath"),
+		"GOMODCACHE=" + s.modCache,
+		"GOENV=off",
+		"GOPROXY=off",
+		"GOSUMDB=off",
+		"GOWORK=off",
+		"GOTOOLCHAIN=local",
+		"GOFLAGS=",
+		"CGO_ENABLED=0",
+	}
+	if race || cfg.AllowCgo {
+		env[len(env)-1] = "CGO_ENABLED=1"
+	}
+	if cfg.CPUSeconds > 0 {
+		env = append(env, "GOLINT_AI_RLIMIT_CPU="+strconv.Itoa(cfg.CPUSeconds))
+	}
+	// -race 会预留大量虚拟地址空间，此时不限制虚拟内存
+	if cfg.MemoryMB > 0 && !race {
+		env = append(env, "GOLINT_AI_RLIMIT_AS="+strconv.Itoa(cfg.MemoryMB*1024))
+	}
+	return env
+}
+
+// goCache 返回沙箱使用的 GOCACHE：默认位于临时目录中，冷启动需要重新编译标准库
+func (s *sandbox) goCache() string {
+	if dir := config.Load().Verify.GoCache; dir != "" {
+		return dir
+	}
+	return filepath.Join(s.root, "cache")
+}
+
+// env 返回在 dir 中运行 go 命令的环境变量
+func (s *sandbox) env(dir string, race bool) ([]string, error) {
+	m, err := s.module(dir)
+	if err != nil {
+		return nil, err
+	}
+	env := s.baseEnv(race)
+	if m.modfile != "" {
+		for i, kv := range env {
+			if kv == "GOFLAGS=" {
+				env[i] = "GOFLAGS=-mod=mod -modfile=" + m.modfile
+			}
+		}
+	}
+	return env, nil
+}
+
+// context 返回带整体超时的 context
+func (s *sandbox) context() (context.Context, context.CancelFunc) {
+	if timeout := config.Load().Verify.Timeout; timeout > 0 {
+		return context.WithTimeout(context.Background(), timeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// command 在 dir 中以沙箱环境运行 go 命令
+func (s *sandbox) command(ctx context.Context, dir string, race bool, args ...string) (*exec.Cmd, error) {
+	env, err := s.env(dir, race)
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.CommandContext(ctx, s.goCmd, args...)
+	cmd.Dir, cmd.Env = dir, env
+	return cmd, nil
+}
+
+func copyFile(src, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, data, 0644)
+}
+
+// copyTree 复制模块目录，跳过版本库与嵌套模块
+func copyTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			if rel != "." {
+				if d.Name() == ".git" || d.Name() == "node_modules" {
+					return filepath.SkipDir
+				}
+				if _, err := os.Stat(filepath.Join(path, "go.mod")); err == nil {
+					return filepath.SkipDir
+				}
+			}
+			return os.MkdirAll(target, 0755)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, info.Mode().Perm())
+	})
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package verifier
+
+// limitedGo 非 unix 平台不支持 rlimit，直接使用真正的 go 命令，只保留环境隔离与超时
+func limitedGo(binDir, realGo string) (string, error) {
+	return realGo, nil
+}
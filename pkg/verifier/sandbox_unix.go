@@ -0,0 +1,25 @@
+//go:build unix
+
+package verifier
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// limitedGo 在 binDir 中生成名为 go 的包装脚本：按环境变量设置 rlimit 后再执行真正的 go
+// 子进程（编译器、测试二进制）会继承这些限制
+func limitedGo(binDir, realGo string) (string, error) {
+	script := fmt.Sprintf(`#!/bin/sh
+[ -n "$GOLINT_AI_RLIMIT_CPU" ] && ulimit -t "$GOLINT_AI_RLIMIT_CPU"
+[ -n "$GOLINT_AI_RLIMIT_AS" ] && ulimit -v "$GOLINT_AI_RLIMIT_AS"
+exec '%s' "$@"
+`, strings.ReplaceAll(realGo, "'", `'\''`))
+	path := filepath.Join(binDir, "go")
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		return "", err
+	}
+	return path, nil
+}
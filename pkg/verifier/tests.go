@@ -0,0 +1,201 @@
+package verifier
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TestOptions 控制补丁的测试校验
+type TestOptions struct {
+	Race    bool          // 以 -race 运行
+	Timeout time.Duration // 单次 go test 的超时，0 表示使用 go test 的默认值
+}
+
+// TestFailure 一个在补丁前通过、补丁后失败的测试
+type TestFailure struct {
+	Name   string
+	Output string // 该测试的输出，最多保留 maxOutputLines 行
+}
+
+func (f TestFailure) String() string {
+	if f.Output == "" {
+		return f.Name
+	}
+	return f.Name + ":\n" + f.Output
+}
+
+const maxOutputLines = 20
+
+// testRun 一次 go test 的结果
+type testRun struct {
+	passed map[string]bool
+	failed map[string]string // 测试名 -> 输出
+	build  string            // 测试无法构建时的输出
+}
+
+var (
+	baselineMu   sync.Mutex
+	baselineRuns = make(map[string]*testRun) // 按目录与选项缓存补丁前的测试结果
+)
+
+// VerifyTests 在沙箱的模块副本中以 overlay 的方式打上补丁并运行 filename 所在包的 go test，源码树不会被修改。
+// 测试二进制以当前用户身份运行，绝对路径的写入与网络访问不受限制
+// 返回补丁前通过、补丁后失败的测试；补丁导致测试无法构建时，补丁前通过的测试全部视为失败
+func VerifyTests(filename string, patched []byte, opts TestOptions) ([]TestFailure, error) {
+	filename, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Dir(filename)
+
+	before, err := baselineTests(dir, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(before.passed) == 0 {
+		return nil, nil // 没有可供对照的测试
+	}
+
+	sb, err := getSandbox()
+	if err != nil {
+		return nil, err
+	}
+	copyDir, _, err := sb.testCopy(dir)
+	if err != nil {
+		return nil, err
+	}
+	tmpDir, err := os.MkdirTemp(filepath.Join(sb.root, "tmp"), "test*")
+	if err != nil {
+		return nil, fmt.Errorf("创建临时目录失败: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	overlayFile, _, err := writeOverlay(tmpDir, filepath.Join(copyDir, filepath.Base(filename)), patched)
+	if err != nil {
+		return nil, err
+	}
+
+	after, err := runTests(dir, opts, "-overlay="+overlayFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var failures []TestFailure
+	for name := range before.passed {
+		switch {
+		case after.build != "":
+			failures = append(failures, TestFailure{Name: name, Output: after.build})
+		case !after.passed[name]:
+			failures = append(failures, TestFailure{Name: name, Output: after.failed[name]})
+		}
+	}
+	sort.Slice(failures, func(i, j int) bool { return failures[i].Name < failures[j].Name })
+	if after.build != "" && len(failures) > 0 {
+		// 构建失败时只报告一次输出
+		for i := 1; i < len(failures); i++ {
+			failures[i].Output = ""
+		}
+	}
+	return failures, nil
+}
+
+// baselineTests 返回补丁前的测试结果，同一个包只运行一次
+func baselineTests(dir string, opts TestOptions) (*testRun, error) {
+	key := fmt.Sprintf("%s|%v|%s", dir, opts.Race, opts.Timeout)
+	baselineMu.Lock()
+	defer baselineMu.Unlock()
+	if run, ok := baselineRuns[key]; ok {
+		return run, nil
+	}
+	run, err := runTests(dir, opts)
+	if err != nil {
+		return nil, err
+	}
+	baselineRuns[key] = run
+	return run, nil
+}
+
+// testEvent go test -json 输出的一行
+type testEvent struct {
+	Action string
+	Test   string
+	Output string
+}
+
+// runTests 在沙箱的模块副本中运行 dir 对应包的 go test -json，并收集每个测试的结果
+func runTests(dir string, opts TestOptions, extraArgs ...string) (*testRun, error) {
+	args := []string{"test", "-json", "-count=1"}
+	if opts.Race {
+		args = append(args, "-race")
+	}
+	ctx := context.Background()
+	if opts.Timeout > 0 {
+		args = append(args, "-timeout="+opts.Timeout.String())
+		// 测试二进制超时后 go test 还需要时间汇报，留出余量再强制结束
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout+time.Minute)
+		defer cancel()
+	}
+	args = append(args, extraArgs...)
+	args = append(args, ".")
+
+	sb, err := getSandbox()
+	if err != nil {
+		return nil, err
+	}
+	copyDir, _, err := sb.testCopy(dir)
+	if err != nil {
+		return nil, err
+	}
+	cmd, err := sb.command(ctx, copyDir, opts.Race, args...)
+	if err != nil {
+		return nil, err
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	runErr := cmd.Run()
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("go test 超时: %w", ctx.Err())
+	}
+
+	run := &testRun{passed: make(map[string]bool), failed: make(map[string]string)}
+	outputs := make(map[string][]string)
+	var buildOutput []string
+	scanner := bufio.NewScanner(&stdout)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	for scanner.Scan() {
+		var ev testEvent
+		if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil {
+			continue // 构建错误等非 JSON 输出
+		}
+		switch {
+		case ev.Action == "build-output":
+			buildOutput = append(buildOutput, strings.TrimRight(ev.Output, "\n"))
+		case ev.Test == "":
+		case ev.Action == "output":
+			if len(outputs[ev.Test]) < maxOutputLines {
+				outputs[ev.Test] = append(outputs[ev.Test], strings.TrimRight(ev.Output, "\n"))
+			}
+		case ev.Action == "pass":
+			run.passed[ev.Test] = true
+		case ev.Action == "fail":
+			run.failed[ev.Test] = strings.Join(outputs[ev.Test], "\n")
+		}
+	}
+
+	if runErr != nil && len(run.passed) == 0 && len(run.failed) == 0 {
+		run.build = strings.TrimSpace(strings.Join(buildOutput, "\n") + "\n" + stderr.String())
+		if run.build == "" {
+			run.build = runErr.Error()
+		}
+	}
+	return run, nil
+}
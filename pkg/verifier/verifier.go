@@ -2,37 +2,289 @@ package verifier
 
 import (
 	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/token"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
 )
 
-// ValidatePatch 校验修复后的代码是否能编译通过
-// 返回值：是否成功，错误信息
-func ValidatePatch(fullCode []byte) (bool, string) {
-	// 1. 创建临时分析目录
-	tmpDir, err := os.MkdirTemp("", "golint_verify_*")
+// Error 一条编译错误，位置已映射回修改前的原文件
+type Error struct {
+	Pos         token.Position // 原文件中的位置，落在补丁内部时指向补丁起始行
+	PatchedLine int            // 修改后文件中的行号，错误不在被修改的文件中时为 0
+	Msg         string
+}
+
+func (e Error) String() string {
+	if !e.Pos.IsValid() {
+		return e.Msg
+	}
+	return fmt.Sprintf("%s: %s", e.Pos, e.Msg)
+}
+
+// Result 一次校验的结果
+type Result struct {
+	Errors  []Error           // 编译错误，为空表示通过
+	Package *packages.Package // 打上补丁后的包，含语法树与类型信息，可用于复查
+}
+
+// OK 判断补丁是否通过校验
+func (r *Result) OK() bool {
+	return len(r.Errors) == 0
+}
+
+// FormatErrors 把编译错误格式化为每行一条的文本，用于反馈给 AI
+func FormatErrors(errs []Error) string {
+	lines := make([]string, len(errs))
+	for i, e := range errs {
+		lines[i] = e.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ValidatePatch 校验修复后的代码能否在其真实的模块与包中通过编译
+// filename 为原文件路径，patched 为修改后的完整内容，通过 overlay 替换原文件，磁盘上的文件不会被修改。
+// 先在沙箱中 go build（测试文件用 go test -c），通过后再用 go/packages 加载出带类型信息的包供复查
+// 返回值：校验结果，以及无法完成校验（如超时）时的错误
+func ValidatePatch(filename string, patched []byte) (*Result, error) {
+	filename, err := filepath.Abs(filename)
 	if err != nil {
-		return false, "创建临时目录失败"
+		return nil, err
 	}
-	defer os.RemoveAll(tmpDir)
+	original, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("无法读取文件: %w", err)
+	}
+	sb, err := getSandbox()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := sb.context()
+	defer cancel()
 
-	tmpFile := filepath.Join(tmpDir, "main.go")
-	if err := os.WriteFile(tmpFile, fullCode, 0644); err != nil {
-		return false, "写入临时文件失败"
+	tmpDir, err := os.MkdirTemp(filepath.Join(sb.root, "tmp"), "verify*")
+	if err != nil {
+		return nil, fmt.Errorf("创建临时目录失败: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	overlayFile, patchedFile, err := writeOverlay(tmpDir, filename, patched)
+	if err != nil {
+		return nil, err
 	}
 
-	// 2. 执行 go build
-	// 我们只进行语法和类型检查，不真正生成二进制文件，这样最快
-	cmd := exec.Command("go", "build", "-o", filepath.Join(tmpDir, "null"), tmpFile)
+	dir := filepath.Dir(filename)
+	lines := newLineMap(original, patched)
+	result := &Result{}
+	seen := make(map[string]bool)
+	addError := func(e Error) {
+		if SameFile(e.Pos.Filename, patchedFile) || SameFile(e.Pos.Filename, filename) {
+			e.PatchedLine = e.Pos.Line
+			e.Pos.Filename = filename
+			e.Pos.Line = lines.original(e.Pos.Line)
+			if e.PatchedLine != e.Pos.Line {
+				e.Pos.Column = 0 // 行已变化，列号对原文件没有意义
+			}
+		}
+		// 同一文件会出现在多个测试变体中，错误只保留一份
+		if key := e.String(); !seen[key] {
+			seen[key] = true
+			result.Errors = append(result.Errors, e)
+		}
+	}
 
+	// 1. 沙箱中编译，AI 代码造成的资源消耗都受到限制
+	args := []string{"build", "-o", os.DevNull}
+	if strings.HasSuffix(filename, "_test.go") {
+		args = []string{"test", "-c", "-o", filepath.Join(tmpDir, "pkg.test")}
+	}
+	cmd, err := sb.command(ctx, dir, false, append(args, "-overlay="+overlayFile, ".")...)
+	if err != nil {
+		return nil, err
+	}
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
-
 	if err := cmd.Run(); err != nil {
-		// 返回编译器的 stderr 输出
-		return false, stderr.String()
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("编译超时: %w", ctx.Err())
+		}
+		for _, e := range parseBuildErrors(dir, stderr.String()) {
+			addError(e)
+		}
+		if len(result.Errors) == 0 {
+			addError(Error{Msg: strings.TrimSpace(stderr.String() + "\n" + err.Error())})
+		}
+		return result, nil
 	}
 
-	return true, ""
+	// 2. 编译通过后加载类型信息，依赖已在同一个 GOCACHE 中编译过
+	env, err := sb.env(dir, false)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles |
+			packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo,
+		Context: ctx,
+		Dir:     dir,
+		Env:     env,
+		Tests:   strings.HasSuffix(filename, "_test.go"),
+		Overlay: map[string][]byte{filename: patched},
+	}
+	pkgs, err := packages.Load(cfg, "file="+filename)
+	if err != nil {
+		return nil, fmt.Errorf("加载包失败: %w", err)
+	}
+	for _, pkg := range pkgs {
+		if !containsFile(pkg, filename) {
+			continue
+		}
+		if result.Package == nil {
+			result.Package = pkg
+		}
+		for _, pe := range compileErrors(pkg) {
+			addError(Error{Pos: parsePos(pe.Pos), Msg: pe.Msg})
+		}
+	}
+	if result.Package == nil {
+		return nil, fmt.Errorf("找不到包含 %s 的包", filename)
+	}
+	return result, nil
+}
+
+// writeOverlay 把补丁后的内容写入 tmpDir，并生成 go 命令 -overlay 使用的映射文件
+func writeOverlay(tmpDir, filename string, patched []byte) (overlayFile, patchedFile string, err error) {
+	patchedFile = filepath.Join(tmpDir, filepath.Base(filename))
+	if err := os.WriteFile(patchedFile, patched, 0644); err != nil {
+		return "", "", fmt.Errorf("写入临时文件失败: %w", err)
+	}
+	overlay, err := json.Marshal(map[string]map[string]string{"Replace": {filename: patchedFile}})
+	if err != nil {
+		return "", "", err
+	}
+	overlayFile = filepath.Join(tmpDir, "overlay.json")
+	if err := os.WriteFile(overlayFile, overlay, 0644); err != nil {
+		return "", "", fmt.Errorf("写入 overlay 失败: %w", err)
+	}
+	return overlayFile, patchedFile, nil
+}
+
+var buildErrorLine = regexp.MustCompile(`^(.+\.go):(\d+)(?::(\d+))?: (.*)$`)
+
+// parseBuildErrors 解析 go build 的错误输出，相对路径相对于 dir；缩进的续行并入上一条错误
+func parseBuildErrors(dir, stderr string) []Error {
+	var errs []Error
+	for _, line := range strings.Split(stderr, "\n") {
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+		case strings.HasPrefix(line, "\t") && len(errs) > 0:
+			errs[len(errs)-1].Msg += "\n" + strings.TrimSpace(line)
+		default:
+			m := buildErrorLine.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			file := m[1]
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(dir, file)
+			}
+			e := Error{Pos: token.Position{Filename: filepath.Clean(file)}, Msg: m[4]}
+			e.Pos.Line, _ = strconv.Atoi(m[2])
+			e.Pos.Column, _ = strconv.Atoi(m[3])
+			errs = append(errs, e)
+		}
+	}
+	return errs
+}
+
+// compileErrors 返回包的错误；已有类型检查或语法错误时丢弃 go list 的错误，
+// 后者是 go list -export 编译时对同一问题的重复报告，且位置指向 overlay 的临时副本
+func compileErrors(pkg *packages.Package) []packages.Error {
+	var checked []packages.Error
+	for _, pe := range pkg.Errors {
+		if pe.Kind != packages.ListError {
+			checked = append(checked, pe)
+		}
+	}
+	if len(checked) > 0 {
+		return checked
+	}
+	return pkg.Errors
+}
+
+// containsFile 判断 filename 是否参与了 pkg 的编译
+func containsFile(pkg *packages.Package, filename string) bool {
+	for _, f := range pkg.CompiledGoFiles {
+		if SameFile(f, filename) {
+			return true
+		}
+	}
+	return false
+}
+
+// SameFile 判断两个路径是否指向同一个文件，会解析符号链接
+func SameFile(a, b string) bool {
+	if a == b {
+		return true
+	}
+	ra, err1 := filepath.EvalSymlinks(a)
+	rb, err2 := filepath.EvalSymlinks(b)
+	return err1 == nil && err2 == nil && ra == rb
+}
+
+// parsePos 解析 packages.Error 中 "file:line:col" 形式的位置，列号与行号都可能缺失
+func parsePos(s string) token.Position {
+	var pos token.Position
+	parts := strings.Split(s, ":")
+	nums := 0
+	for i := len(parts) - 1; i > 0 && nums < 2; i-- {
+		n, err := strconv.Atoi(parts[i])
+		if err != nil {
+			break
+		}
+		pos.Column, pos.Line = pos.Line, n
+		nums++
+	}
+	if nums == 0 {
+		return token.Position{}
+	}
+	pos.Filename = strings.Join(parts[:len(parts)-nums], ":")
+	return pos
+}
+
+// lineMap 把修改后文件的行号映射回原文件：补丁前后未变的行一一对应，补丁内部的行指向补丁起始行
+type lineMap struct {
+	prefix  int // 开头相同的行数
+	suffix  int // 结尾相同的行数
+	patched int // 修改后文件的总行数
+	orig    int // 原文件的总行数
+}
+
+func newLineMap(original, patched []byte) lineMap {
+	a, b := bytes.Split(original, []byte("\n")), bytes.Split(patched, []byte("\n"))
+	m := lineMap{patched: len(b), orig: len(a)}
+	for m.prefix < len(a) && m.prefix < len(b) && bytes.Equal(a[m.prefix], b[m.prefix]) {
+		m.prefix++
+	}
+	for m.suffix < len(a)-m.prefix && m.suffix < len(b)-m.prefix &&
+		bytes.Equal(a[len(a)-1-m.suffix], b[len(b)-1-m.suffix]) {
+		m.suffix++
+	}
+	return m
+}
+
+func (m lineMap) original(line int) int {
+	switch {
+	case line <= m.prefix:
+		return line
+	case line > m.patched-m.suffix:
+		return line - (m.patched - m.orig)
+	default:
+		return m.prefix + 1
+	}
 }